@@ -0,0 +1,95 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"zero-system/guard"
+	"zero-system/pow"
+	"zero-system/ratelimit"
+)
+
+// Admission policies for /api/send and /api/read.
+const (
+	AdmitRateLimit   = "ratelimit" // Per-IP token buckets only
+	AdmitProofOfWork = "pow"       // Client puzzles only, for botnets and shared NAT
+	AdmitBoth        = "both"      // Token buckets, then client puzzles
+)
+
+// powGrace is how many bits below the current admission difficulty a
+// solution may be, so a puzzle fetched just before load rose still counts.
+const powGrace = 2
+
+// requirePow reports whether sends and reads must carry a solved puzzle.
+func (s *Server) requirePow() bool {
+	return s.admission == AdmitProofOfWork || s.admission == AdmitBoth
+}
+
+// admit wraps an admission-controlled handler in the configured policy and
+// counts it towards the load that drives puzzle difficulty.
+func (s *Server) admit(limiter *ratelimit.Limiter, next http.HandlerFunc) http.HandlerFunc {
+	tracked := func(w http.ResponseWriter, r *http.Request) {
+		s.inFlight.Add(1)
+		defer s.inFlight.Add(-1)
+		next(w, r)
+	}
+	if s.admission == AdmitProofOfWork {
+		return tracked
+	}
+	return limiter.Middleware(tracked)
+}
+
+// pressure is the current load or failure rate, whichever is higher,
+// on a scale from 0 (idle) to 1 (saturated or under attack).
+func (s *Server) pressure() float64 {
+	load := float64(s.inFlight.Load()) / float64(s.maxInFlight)
+	failures := float64(s.guard.GlobalStage()) / float64(guard.StageLockdown)
+	return max(load, failures)
+}
+
+// sendWork returns the difficulty to issue to a sender and the least
+// difficulty accepted from one. Both are 0 when no work is required.
+func (s *Server) sendWork() (issue, accept int) {
+	if !s.requirePow() {
+		return 0, 0
+	}
+	issue = pow.Scale(s.powBase, s.powMax, s.pressure())
+	return issue, max(issue-powGrace, s.powBase)
+}
+
+// readWork is sendWork for a reader in stage. A challenged reader must
+// also meet the brute-force difficulty.
+func (s *Server) readWork(stage guard.Stage) (issue, accept int) {
+	issue, accept = s.sendWork()
+	if stage >= guard.StageChallenge {
+		issue = max(issue, s.challengeBits)
+		accept = max(accept, s.challengeBits)
+	}
+	return issue, accept
+}
+
+// ChallengeRequest optionally asks for a harder puzzle, e.g. to meet an
+// entry's pow() policy.
+type ChallengeRequest struct {
+	Bits int `json:"bits"`
+}
+
+// HandleChallenge hands out a puzzle at the current send difficulty, or
+// harder on request, in the same padded envelope as a read.
+func (s *Server) HandleChallenge(w http.ResponseWriter, r *http.Request) {
+	enableCors(w)
+	if r.Method == "OPTIONS" {
+		return
+	}
+
+	var req ChallengeRequest
+	json.NewDecoder(r.Body).Decode(&req) // The body is optional
+	issue, _ := s.sendWork()
+	if issue == 0 {
+		issue = s.powBase
+	}
+	if req.Bits > issue && req.Bits <= 32 {
+		issue = req.Bits
+	}
+	writeResponse(w, ReadResponse{Challenge: s.pow.Challenge(issue)})
+}
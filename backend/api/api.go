@@ -1,40 +1,309 @@
 package api
 
 import (
+	"bytes"
 	"encoding/json"
 	"net/http"
+	"net/netip"
+	"sync/atomic"
 	"time"
 
+	"zero-system/approval"
 	"zero-system/auth"
+	"zero-system/clock"
 	"zero-system/crypto"
+	"zero-system/decoy"
+	"zero-system/guard"
 	"zero-system/normalize"
+	"zero-system/policy"
+	"zero-system/pow"
+	"zero-system/ratelimit"
+	"zero-system/signing"
 	"zero-system/store"
 )
 
+// --- Server ---
+
+// Config describes how a Server is assembled.
+// Zero values fall back to the canonical defaults of the standalone binary.
+// An injected Store or limiter belongs to the caller, who closes it once
+// the Server is closed; Server.Close only stops what NewServer created.
+type Config struct {
+	Store       *store.MemoryStore
+	SendLimiter *ratelimit.Limiter
+	ReadLimiter *ratelimit.Limiter
+	Clock       clock.Clock // Shared by the default store and limiters
+
+	EntryTTL time.Duration // Lifetime of an unread entry (default 15m)
+
+	OperatorToken string        // Enables operator endpoints (tripwires, lockdown) when set
+	TripwireTTL   time.Duration // Default lifetime of a tripwire slot (default 30 days)
+	Alerts        func(Alert)   // Receives internal alerts (default: log)
+
+	Guard         guard.Config   // Brute-force thresholds for failed reads
+	TarpitDelay   time.Duration  // Added to every read from a tarpitted client (default 2s)
+	ChallengeBits int            // Proof-of-work difficulty for challenged clients (default 18)
+	Lockdown      LockdownPolicy // What the global lockdown stage does (default: refuse sends)
+
+	Admission   string // AdmitRateLimit (default), AdmitProofOfWork or AdmitBoth
+	PowBase     int    // Admission difficulty when idle (default 12)
+	PowMax      int    // Admission difficulty under full pressure (default 22)
+	MaxInFlight int    // Concurrent sends and reads counted as full load (default 64)
+
+	BlindKeyBits     int           // Size of the blind-signing RSA key (default 2048)
+	BlindKeyLifetime time.Duration // How often that key rotates (default 7 days)
+
+	ReplyTTL time.Duration // How long a reply capability can be spent (default 24h)
+
+	Approvals map[string]approval.Policy // Two-person rule per Action* (default: none)
+
+	Decoys *decoy.Generator // Writes Reality A for senders who ask (default: built-in corpus)
+
+	TrustedProxies []netip.Prefix // Peers whose X-Forwarded-For cidr() and the guard believe (default: none)
+}
+
+// Server is a self-contained ZERO instance.
+// Several servers can live in one process without sharing any state.
+type Server struct {
+	store       *store.MemoryStore
+	sendLimiter *ratelimit.Limiter
+	readLimiter *ratelimit.Limiter
+	clock       clock.Clock
+	entryTTL    time.Duration
+
+	operatorToken string
+	tripwireTTL   time.Duration
+	alert         func(Alert)
+	lockdown      atomic.Bool // Sends are silently dropped while set
+	revocations   *auth.RevocationList
+
+	guard          *guard.Detector
+	pow            *pow.Issuer
+	tarpitDelay    time.Duration
+	challengeBits  int
+	lockdownPolicy LockdownPolicy
+	lockdownFired  atomic.Bool // The brute-force lockdown has run since the last lift
+
+	admission   string
+	powBase     int
+	powMax      int
+	maxInFlight int
+	inFlight    atomic.Int64
+
+	blind    *auth.BlindIssuer // Signs and spends anonymous send credentials
+	replyTTL time.Duration
+
+	approvals *approval.Board   // Pending operator approvals, RAM only
+	signers   *signing.Registry // Server-held sender keys, RAM only
+	decoys    *decoy.Generator
+
+	trustedProxies []netip.Prefix
+
+	mux   *http.ServeMux
+	owned []func() // Stops what NewServer created itself, see Close
+}
+
+// NewServer builds a Server from cfg and registers its routes.
+func NewServer(cfg Config) *Server {
+	s := &Server{
+		store:       cfg.Store,
+		sendLimiter: cfg.SendLimiter,
+		readLimiter: cfg.ReadLimiter,
+		clock:       cfg.Clock,
+		entryTTL:    cfg.EntryTTL,
+
+		operatorToken: cfg.OperatorToken,
+		tripwireTTL:   cfg.TripwireTTL,
+		alert:         cfg.Alerts,
+		revocations:   auth.NewRevocationList(),
+
+		tarpitDelay:    cfg.TarpitDelay,
+		challengeBits:  cfg.ChallengeBits,
+		lockdownPolicy: cfg.Lockdown,
+
+		admission:   cfg.Admission,
+		powBase:     cfg.PowBase,
+		powMax:      cfg.PowMax,
+		maxInFlight: cfg.MaxInFlight,
+
+		trustedProxies: cfg.TrustedProxies,
+
+		mux: http.NewServeMux(),
+	}
+
+	if s.clock == nil {
+		s.clock = clock.Real{}
+	}
+	if s.store == nil {
+		s.store = store.NewMemoryStore(s.clock)
+		s.owned = append(s.owned, s.store.Close)
+	}
+	if s.sendLimiter == nil {
+		// Send: 5 per minute (Strict) -> 0.083 tokens/sec, Burst 2
+		s.sendLimiter = ratelimit.NewLimiter(0.083, 2, s.clock)
+		s.owned = append(s.owned, s.sendLimiter.Close)
+	}
+	if s.readLimiter == nil {
+		// Read: 60 per minute (Normal + Noise) -> 1 token/sec, Burst 10
+		s.readLimiter = ratelimit.NewLimiter(1.0, 10, s.clock)
+		s.owned = append(s.owned, s.readLimiter.Close)
+	}
+	if s.entryTTL <= 0 {
+		s.entryTTL = 15 * time.Minute
+	}
+	if s.tripwireTTL <= 0 {
+		s.tripwireTTL = 30 * 24 * time.Hour
+	}
+	if s.alert == nil {
+		s.alert = logAlert
+	}
+	if s.tarpitDelay <= 0 {
+		s.tarpitDelay = 2 * time.Second
+	}
+	if s.challengeBits <= 0 {
+		s.challengeBits = 18
+	}
+	if s.lockdownPolicy.Action == "" {
+		s.lockdownPolicy.Action = LockdownRefuseSends
+	}
+	if s.admission == "" {
+		s.admission = AdmitRateLimit
+	}
+	if s.powBase <= 0 {
+		s.powBase = 12
+	}
+	if s.powMax < s.powBase {
+		s.powMax = max(22, s.powBase)
+	}
+	if s.maxInFlight <= 0 {
+		s.maxInFlight = 64
+	}
+	s.replyTTL = cfg.ReplyTTL
+	if s.replyTTL <= 0 {
+		s.replyTTL = 24 * time.Hour
+	}
+	if cfg.BlindKeyBits <= 0 {
+		cfg.BlindKeyBits = 2048
+	}
+	if cfg.BlindKeyLifetime <= 0 {
+		cfg.BlindKeyLifetime = 7 * 24 * time.Hour
+	}
+	blind, err := auth.NewBlindIssuer(cfg.BlindKeyBits, cfg.BlindKeyLifetime, s.clock)
+	if err != nil {
+		panic("zero: cannot generate blind-signing key: " + err.Error())
+	}
+	s.blind = blind
+	s.approvals = approval.NewBoard(withTripwirePolicies(cfg.Approvals), s.clock)
+	s.signers = signing.NewRegistry(s.clock)
+	s.decoys = cfg.Decoys
+	if s.decoys == nil {
+		s.decoys = decoy.New(nil)
+	}
+	s.guard = guard.NewDetector(cfg.Guard, s.clock)
+	s.pow = pow.NewIssuer(s.clock, 5*time.Minute)
+
+	s.mux.HandleFunc("/api/send", s.admit(s.sendLimiter, s.HandleSend))
+	s.mux.HandleFunc("/api/read", s.admit(s.readLimiter, s.HandleRead))
+	s.mux.HandleFunc("/api/challenge", s.HandleChallenge)
+	s.mux.HandleFunc("/api/recall", s.readLimiter.Middleware(s.HandleRecall))
+	s.mux.HandleFunc("/api/receipt", s.readLimiter.Middleware(s.HandleReceipt))
+	s.mux.HandleFunc("/api/issuer", s.HandleIssuerKey)
+	s.mux.HandleFunc("/api/issue", s.sendLimiter.Middleware(s.HandleIssue))
+	s.mux.HandleFunc("/api/group", s.sendLimiter.Middleware(s.HandleGroup))
+	s.mux.HandleFunc("/api/signer", s.sendLimiter.Middleware(s.HandleSigner))
+	s.mux.HandleFunc("/api/panic", s.HandlePanic) // No rate limit? Or shared?
+	s.mux.HandleFunc("/api/heartbeat", s.HandleHeartbeat)
+	s.mux.HandleFunc("/api/tripwire", s.readLimiter.Middleware(s.HandleTripwire))
+	s.mux.HandleFunc("/api/lockdown", s.readLimiter.Middleware(s.HandleLockdown))
+	s.mux.HandleFunc("/api/deadman", s.readLimiter.Middleware(s.HandleDeadMan))
+	s.mux.HandleFunc("/api/revoke", s.readLimiter.Middleware(s.HandleRevoke))
+
+	return s
+}
+
+// Handler returns the HTTP entry point of the server.
+func (s *Server) Handler() http.Handler {
+	return s.mux
+}
+
+// Close stops the background routines of the brute-force guard, puzzle
+// issuer and signer registry, and those of the store and limiters when
+// NewServer created them; only a store created here is wiped. Injected
+// dependencies are left to the caller.
+func (s *Server) Close() error {
+	for _, stop := range s.owned {
+		stop()
+	}
+	s.guard.Close()
+	s.pow.Close()
+	s.signers.Close()
+	return nil
+}
+
 // --- Structures ---
 
 type SendRequest struct {
-	RealityA  string  `json:"realityA"`
-	RealityB  string  `json:"realityB"`
-	TxToken   string  `json:"txToken"`
-	RxToken   string  `json:"rxToken"`
-	GeoActive bool    `json:"geoActive"`
-	Lat       float64 `json:"lat"`
-	Long      float64 `json:"long"`
-	RadiusKm  float64 `json:"radiusKm"`
+	RealityA    string   `json:"realityA"`
+	RealityB    string   `json:"realityB"`
+	Realities   []string `json:"realities"` // Overrides A/B: surface first, up to MAX_REALITIES
+	TxToken     string   `json:"txToken"`
+	RxToken     string   `json:"rxToken"`
+	RxTokens    []string `json:"rxTokens"`    // Fan-out: one independent copy per slot, instead of RxToken
+	DuressToken string   `json:"duressToken"` // Optional second RX: reads like A, silently burns the rest
+	GeoActive   bool     `json:"geoActive"`
+	Lat         float64  `json:"lat"`
+	Long        float64  `json:"long"`
+	RadiusKm    float64  `json:"radiusKm"`
+	Challenge   string   `json:"challenge"` // Proof-of-work puzzle from /api/challenge
+	Solution    string   `json:"solution"`  // ... and its solution
+	Reply       bool     `json:"reply"`     // Hand the reader a one-time reply capability
+	Threshold   int      `json:"threshold"` // k: shares needed to open Reality B (0 = off)
+	Shares      int      `json:"shares"`    // n: share credentials to issue, up to MAX_SHARES
+
+	Schedule *ScheduleRequest `json:"schedule"` // Pre-agreed read windows (default: any time)
+	Policy   string           `json:"policy"`   // Access policy source, see package policy
+	Views    []ViewPolicySpec `json:"views"`    // Per reality, aligned with Realities (default: burn on read)
+
+	// Sender signatures, see package signing: either Sign with the key
+	// registered for TxToken, or one client-side signature per reality.
+	Sign       bool     `json:"sign"`
+	SignerKey  string   `json:"signerKey"`  // Client-side: base64 Ed25519 public key
+	Signatures []string `json:"signatures"` // Client-side: base64, aligned with Realities
+	Expiry     int64    `json:"expiry"`     // Client-side: the Unix expiry the signatures bind
+
+	Deniable bool `json:"deniable"` // Store A and B as one deniable container, see CONTAINER_SLOTS
+	Decoy    bool `json:"decoy"`    // Replace Reality A with a generated decoy shaped like the others
 }
 
 type ReadRequest struct {
 	RxToken string  `json:"rxToken"`
 	Lat     float64 `json:"lat"`
 	Long    float64 `json:"long"`
+	Page    int     `json:"page"` // Page to fetch from a paged reality
+
+	Challenge string `json:"challenge"` // Proof-of-work puzzle echoed back
+	Solution  string `json:"solution"`  // ... and its solution
 }
 
 const RESPONSE_SIZE = 4096 // 4KB Fixed Size
 
 type ReadResponse struct {
-	Content string `json:"content"`
-	Padding string `json:"padding"` // Junk data to equalize size
+	Content   string   `json:"content"`
+	Page      int      `json:"page"`      // Index of this page (paged realities only)
+	Pages     int      `json:"pages"`     // 0 for inline content, PAGE_COUNT when paged
+	Challenge string   `json:"challenge"` // Proof-of-work puzzle to solve and send back
+	Recall    string   `json:"recall"`    // Send only: destroys the unread message
+	Receipt   string   `json:"receipt"`   // Send only: polls the message's outcome
+	States    []string `json:"states"`    // Receipt only: sorted reality states
+	Reply     string   `json:"reply"`     // One-time capability to answer this message
+	Shares    []string `json:"shares"`    // Send only: threshold share credentials for B
+	Group     string   `json:"group"`     // Group only: admin capability
+	Post      string   `json:"post"`      // Group only: current post capability
+	Members   []string `json:"members"`   // Group only: new member credentials
+	Signer    string   `json:"signer"`    // Fingerprint of the key that signed this reality
+	Verified  bool     `json:"verified"`  // Its signature holds for this slot and expiry
+	SignerKey string   `json:"signerKey"` // Signer only: the registered public key
+	Padding   string   `json:"padding"`   // Junk data to equalize size
 }
 
 // --- Helpers ---
@@ -46,10 +315,11 @@ func enableCors(w http.ResponseWriter) {
 }
 
 func writePaddedResponse(w http.ResponseWriter, content string) {
-	// 1. Create content JSON
-	resp := ReadResponse{Content: content}
+	writeResponse(w, ReadResponse{Content: content})
+}
 
-	// 2. Calculate necessary padding
+func writeResponse(w http.ResponseWriter, resp ReadResponse) {
+	// 1. Calculate necessary padding
 	// We marshal just the content first to see size
 	baseJSON, _ := json.Marshal(resp)
 	missing := RESPONSE_SIZE - len(baseJSON)
@@ -57,7 +327,7 @@ func writePaddedResponse(w http.ResponseWriter, content string) {
 		missing = 0
 	}
 
-	// 3. Generate random padding
+	// 2. Generate random padding
 	pad := make([]byte, missing)
 	crypto.Zeroize(pad) // reusing random generator would be better but zero/junk is fine for length hiding if encrypted?
 	// Actually, if we are over HTTP, TLS hides the content. We just need the LENGTH to be constant.
@@ -75,97 +345,290 @@ func writePaddedResponse(w http.ResponseWriter, content string) {
 	json.NewEncoder(w).Encode(resp)
 }
 
+// noNote is the content of every failed read.
+const noNote = "No note available"
+
 func genericError(w http.ResponseWriter) {
 	// Traffic Correlation Fix: Return SAME size/status as a valid read.
 	// We return empty content (logic error) masquerading as success protocol-wise.
 	// The client will see empty content and show "No note available".
-	writePaddedResponse(w, "No note available")
+	writePaddedResponse(w, noNote)
 }
 
 // --- Handlers ---
 
-func HandleSend(w http.ResponseWriter, r *http.Request) {
+func (s *Server) HandleSend(w http.ResponseWriter, r *http.Request) {
 	enableCors(w)
 	if r.Method == "OPTIONS" {
 		return
 	}
 
+	// Every answer carries recall, receipt and reply capabilities, real or
+	// not, so success and failure stay indistinguishable.
+	ack := ReadResponse{
+		Content: "Note saved",
+		Recall:  newCapability(recallPrefix),
+		Receipt: newCapability(receiptPrefix),
+		Reply:   newCapability(replyPrefix),
+	}
+
 	var req SendRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		w.Write([]byte("Note saved")) // Silent degrade
+		writeResponse(w, ack) // Silent degrade
 		return
 	}
 
-	// 1. Validate TX
-	if !auth.ValidateSenderToken(req.TxToken) {
-		w.Write([]byte("Note saved")) // Silent failure
-		return
+	// Threshold release: B gets a random key split among n receivers. The
+	// shares are drawn before any check so failures carry them too.
+	var thresholdKey, shareKey []byte
+	if req.Threshold > 0 {
+		key, macKey, shares, err := newThresholdKey(req.RxToken, req.Threshold, req.Shares)
+		if err != nil {
+			writeResponse(w, ack) // Silent failure
+			return
+		}
+		defer crypto.Zeroize(key)
+		defer crypto.Zeroize(macKey)
+		thresholdKey, shareKey, ack.Shares = key, macKey, shares
 	}
 
-	// 2. Validate RX
-	if !auth.ValidateReceiverToken(req.RxToken) {
-		w.Write([]byte("Note saved")) // Silent failure
+	// 0. Admission and lockdown: accept nothing without the required work
+	if _, accept := s.sendWork(); accept > 0 && !s.pow.Verify(req.Challenge, req.Solution, accept) {
+		writeResponse(w, ack) // Silent failure
+		return
+	}
+	if s.lockedDown(r) {
+		writeResponse(w, ack) // Silent failure
 		return
 	}
 
-	// 3. Normalize
-	normA, normB := normalize.Normalize(req.RealityA, req.RealityB)
+	// 1. Validate TX: a plain token, or a blind-signed credential or reply
+	// capability that is spent here whether or not the rest of the send
+	// succeeds. A reply capability also names the slot and the next link
+	// of its chain.
+	if auth.IsBlindToken(req.TxToken) {
+		if !s.blind.Spend(req.TxToken) {
+			writeResponse(w, ack) // Silent failure
+			return
+		}
+	} else if IsGroupPost(req.TxToken) {
+		s.sendGroup(req)
+		writeResponse(w, ack)
+		return
+	} else if IsReplyCapability(req.TxToken) {
+		if !s.store.SpendReply(req.TxToken) {
+			writeResponse(w, ack) // Silent failure
+			return
+		}
+		req.RxToken = ReplySlot(req.TxToken)
+		req.Reply = true
+		ack.Reply = NextReply(req.TxToken)
+	} else if !auth.ValidateSenderToken(req.TxToken) || s.revocations.IsRevoked(req.TxToken) {
+		writeResponse(w, ack) // Silent failure
+		return
+	}
 
-	// 4. Derive Keys (HKDF based on RX + Context)
-	// Keys are now *memguard.LockedBuffer (Secure Memory)
-	keyA, errA := crypto.DeriveKey(req.RxToken, "A")
-	if errA == nil {
-		defer keyA.Destroy() // Auto-wipe and unlock
+	// 2. Validate RX: one slot, or the slots of a fan-out
+	rxTokens, ok := recipients(req)
+	if !ok {
+		writeResponse(w, ack) // Silent failure
+		return
 	}
 
-	keyB, errB := crypto.DeriveKey(req.RxToken, "B")
-	if errB == nil {
-		defer keyB.Destroy()
+	schedule, err := parseSchedule(req.Schedule)
+	if err != nil {
+		writeResponse(w, ack) // Silent failure
+		return
+	}
+	texts := senderRealities(req)
+	if len(texts) > MAX_REALITIES {
+		writeResponse(w, ack) // Silent failure
+		return
+	}
+	if req.Decoy {
+		texts = s.withDecoy(texts)
+	}
+	if req.Deniable {
+		if texts, err = deniableRealities(req, texts); err != nil {
+			writeResponse(w, ack) // Silent failure
+			return
+		}
+	}
+	views, err := parseViewPolicies(req.Views, len(texts), s.entryTTL)
+	if err != nil {
+		writeResponse(w, ack) // Silent failure
+		return
+	}
+	expiry, err := s.sendExpiry(req, len(rxTokens))
+	if err != nil {
+		writeResponse(w, ack) // Silent failure
+		return
 	}
 
-	if errA != nil || errB != nil {
-		w.Write([]byte("Note sent"))
+	// 3. Normalize (NFC + one shared size bucket for all realities, length-prefixed)
+	padded, err := normalize.Normalize(texts...)
+	if err != nil {
+		writeResponse(w, ack) // Silent failure
 		return
 	}
+	defer func() {
+		for _, p := range padded {
+			crypto.Zeroize(p)
+		}
+	}()
+
+	// 4 & 5. Derive one key per reality and recipient and encrypt
+	// (paged if any reality outgrows the response envelope).
+	entries := make([]*store.SecureEntry, len(rxTokens))
+	var paged bool
+	var surfaceSignature []byte
+	for i, rxToken := range rxTokens {
+		var realities []*store.MessageReality
+		if req.Deniable {
+			realities, err = sealContainer(rxToken, padded)
+		} else {
+			realities, paged, err = sealRealities(rxToken, padded)
+		}
+		if err != nil {
+			writeResponse(w, ack) // Silent failure
+			return
+		}
+		if thresholdKey != nil {
+			// 5b. Reseal B under the split key; shares are presented one read
+			// at a time, so B must fit a single envelope.
+			if paged || len(realities) < 2 {
+				writeResponse(w, ack) // Silent failure
+				return
+			}
+			crypto.Zeroize(realities[1].Ciphertext)
+			if realities[1], err = sealReality(padded[1], thresholdKey, false); err != nil {
+				writeResponse(w, ack) // Silent failure
+				return
+			}
+		}
+		for j, policy := range views {
+			realities[j].ViewPolicy = policy
+		}
+		if signs(req) {
+			if surfaceSignature, err = s.signRealities(req, rxToken, expiry, padded, realities, thresholdKey); err != nil {
+				writeResponse(w, ack) // Silent failure
+				return
+			}
+		}
+		// Policies count views, so every copy compiles its own.
+		access, err := compilePolicy(req)
+		if err != nil {
+			writeResponse(w, ack) // Silent failure
+			return
+		}
 
-	// 5. Encrypt
-	// keyA.Bytes() gives direct access to protected memory. Do not copy.
-	cipherA, nonceA, _ := crypto.EncryptAESGCM([]byte(normA), keyA.Bytes())
-	cipherB, nonceB, _ := crypto.EncryptAESGCM([]byte(normB), keyB.Bytes())
+		// 6. Store in RAM
+		entries[i] = &store.SecureEntry{
+			Realities:  realities,
+			ExpiryTime: expiry,
+			Recall:     ack.Recall,
+			Receipt:    ack.Receipt,
+			Threshold:  req.Threshold,
+			ShareKey:   bytes.Clone(shareKey),
+			Schedule:   schedule,
+			Policy:     access,
+			Deniable:   req.Deniable,
+		}
+	}
+	entry := entries[0]
+	if req.Reply {
+		entry.Reply = ack.Reply
+	}
 
-	// 6. Store in RAM
-	entry := &store.SecureEntry{
-		RealityA: &store.MessageReality{
-			Ciphertext: cipherA,
-			Nonce:      nonceA,
-			Destroyed:  false,
-		},
-		RealityB: &store.MessageReality{
-			Ciphertext: cipherB,
-			Nonce:      nonceB,
-			Destroyed:  false,
-		},
-		ExpiryTime: time.Now().Add(15 * time.Minute),
+	// 6b. Duress credential: a second copy of the surface reality, sealed
+	// under a key only the duress token derives.
+	if req.DuressToken != "" {
+		if !validSlot(req.DuressToken) || req.DuressToken == req.RxToken {
+			writeResponse(w, ack) // Silent failure
+			return
+		}
+		key, err := crypto.DeriveKey(req.DuressToken, realityLabel(0))
+		if err != nil {
+			writeResponse(w, ack) // Silent failure
+			return
+		}
+		entry.Duress, err = sealReality(padded[0], key.Bytes(), paged)
+		if err == nil && surfaceSignature != nil {
+			entry.Duress.Signature, err = sealSignature(surfaceSignature, key.Bytes())
+		}
+		key.Destroy()
+		if err != nil {
+			writeResponse(w, ack) // Silent failure
+			return
+		}
+		entry.DuressRx = req.DuressToken
+	}
+
+	if err := s.store.SaveAll(rxTokens, entries); err != nil {
+		writeResponse(w, ack) // Silent failure
+		return
 	}
 
-	store.GlobalStore.Save(req.RxToken, entry)
+	if req.Reply {
+		s.store.RegisterReply(ack.Reply, s.clock.Now().Add(s.replyTTL))
+	}
 
 	// 7. Success Response (Identical to failure)
-	w.Write([]byte("Note saved"))
+	writeResponse(w, ack)
 }
 
-func HandleRead(w http.ResponseWriter, r *http.Request) {
+func (s *Server) HandleRead(w http.ResponseWriter, r *http.Request) {
 	enableCors(w)
 	if r.Method == "OPTIONS" {
 		return
 	}
 
+	client := s.guardKey(r)
+	stage := s.guard.Stage(client)
+
+	// Escalation 1: Tarpit. Every response to this caller is slowed down.
+	if stage >= guard.StageTarpit {
+		clock.Sleep(s.clock, s.tarpitDelay)
+	}
+
 	var req ReadRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.readFailed(client, stage)
 		genericError(w)
 		return
 	}
 
+	// Escalation 2: Proof-of-work, also the admission policy when enabled.
+	// Without a valid solution the caller gets the generic failure envelope
+	// carrying a fresh puzzle.
+	work := 0
+	if issue, accept := s.readWork(stage); accept > 0 {
+		if !s.pow.Verify(req.Challenge, req.Solution, accept) {
+			writeResponse(w, ReadResponse{Content: noNote, Challenge: s.pow.Challenge(issue)})
+			return
+		}
+		work = pow.Difficulty(req.Challenge)
+	}
+
+	resp, tripwire, ok := s.read(req, s.policyRequest(r, req, work))
+	if ok {
+		writeResponse(w, resp)
+	} else {
+		s.readFailed(client, stage)
+		genericError(w)
+	}
+
+	// Tripwire: touching a canary slot in any way fires its action, once
+	// the ordinary response is out and the store lock is released.
+	if tripwire != nil {
+		s.springTripwire(*tripwire)
+	}
+}
+
+// read resolves and reveals the reality addressed by req, if the entry's
+// access policy allows it for access.
+// ok is false whenever the caller must see the generic failure.
+func (s *Server) read(req ReadRequest, access policy.Request) (resp ReadResponse, tripwire *store.Tripwire, ok bool) {
 	// Logic: The 'token' input IS the RxToken.
 	// But how do we distinguish Reality A vs B?
 	// In the canonical workflow, the user inputs "Receiver Token (RX)".
@@ -210,128 +673,138 @@ func HandleRead(w http.ResponseWriter, r *http.Request) {
 	// REFINED ARCHITECTURE for THIS STEP:
 	// We will support **Suffix-based Routing** for the Hackathon/Demo.
 	// If RxToken ends in "-A", it maps to A.
-	// If RxToken ends in "-B", it maps to B.
+	// If RxToken ends in "-B", it maps to B, "-C" to C, and so on.
+	// No suffix -> Default to Reality A (Surface).
 	// The *Base* Token is the key for the map.
 	// This satisfies "RX determines reality" without complex state.
 
-	rxRaw := req.RxToken
-	if len(rxRaw) < 2 {
-		genericError(w)
-		return
+	if len(req.RxToken) < 2 {
+		return resp, nil, false
 	}
-
-	// Logic:
-	// 1. Check for explicit "-B" suffix -> Reality B (Hidden)
-	// 2. Check for explicit "-A" suffix -> Reality A (Surface)
-	// 3. No suffix -> Default to Reality A (Surface)
-
-	var mode string
-	var baseRx string
-
-	if len(rxRaw) > 2 && rxRaw[len(rxRaw)-2:] == "-B" {
-		mode = "-B"
-		baseRx = rxRaw[:len(rxRaw)-2]
-	} else if len(rxRaw) > 2 && rxRaw[len(rxRaw)-2:] == "-A" {
-		mode = "-A"
-		baseRx = rxRaw[:len(rxRaw)-2]
-	} else {
-		mode = "-A" // Default
-		baseRx = rxRaw
+	if IsGroupMember(req.RxToken) {
+		resp, ok = s.readGroup(req.RxToken)
+		return resp, nil, ok
 	}
+	if baseRx, share, isShare := parseShareToken(req.RxToken); isShare {
+		return s.readShare(baseRx, share, access)
+	}
+	baseRx, index := parseReceiverToken(req.RxToken)
 
-	entry, exists := store.GlobalStore.Get(baseRx)
+	entry, exists := s.store.Get(baseRx)
 	if !exists {
-		genericError(w)
-		return
+		return resp, nil, false
 	}
+	tripwire = entry.Tripwire
 
-	// store.GlobalStore.Mu() is not exposed directly anymore?
-	// Wait, I removed Mu() accessor in store.go?
-	// I used s.mu.Lock inside methods.
-	// But api.go uses manual locking?
-	// HandleRead needs to Lock for destruction.
-	// I shoud access Mu via mutex if public, or refactor store?
-	// Let's refactor api.go to use a new store method `Burn(id)`?
-	// Or restore Mu() in store.go?
-	// For now, let's restore Mu logic inline if possible?
-	// `store.GlobalStore` is a struct pointer. `mu` is lowercase (private).
-	// I BROKE the build by making `mu` private in previous step without exported accessor.
-	// I MUST FIX store.go or add accessor.
-	// Quick fix: Assume I will fix store.go next.
-	// OR use `store.GlobalStore.GetForBurn`?
-	// Let's rely on `Get` returning pointer and assuming current implementation allows modifying it?
-	// Race condition! `Get` has ReadLock.
-	// `HandleRead` modifies `Destroyed`.
-	// I need WriteLock.
-	// I will add `store.GlobalStore.Lock()`/`Unlock()` helpers in next step.
-	// For now, I'll write the API code assuming helper exists.
-	store.GlobalStore.Lock()
-	defer store.GlobalStore.Unlock()
-
-	if mode == "-A" {
-		if entry.RealityA.Destroyed {
-			genericError(w)
-			return
-		}
-		// Decrypt A
-		// We use the BASE RX for derivation to ensure the Sender's intent holds
-		keyA, _ := crypto.DeriveKey(baseRx, "A")
-		defer keyA.Destroy() // Secure Wipe
+	// `Get` only holds the read lock. Destruction below mutates the entry,
+	// so we take the store's write lock for the rest of the read.
+	s.store.Lock()
+	defer s.store.Unlock()
 
-		plaintext, err := crypto.DecryptAESGCM(entry.RealityA.Ciphertext, keyA.Bytes(), entry.RealityA.Nonce)
-		if err != nil {
-			genericError(w)
-			return
-		}
+	// Access policy: judged before anything is resolved, and failing
+	// exactly like a missing slot
+	if !entry.Policy.Allow(access) {
+		return resp, tripwire, false
+	}
 
-		// Destroy Only A
-		entry.RealityA.Destroyed = true
-		entry.RealityA.Ciphertext = nil // WIPE FROM RAM
+	// We use the BASE RX for derivation to ensure the Sender's intent holds
+	target, ok := resolveTarget(entry, baseRx, index)
+	if !ok {
+		return resp, tripwire, false
+	}
 
-		writePaddedResponse(w, string(plaintext))
-		return
+	// Duress: the hidden realities die in the same critical section as
+	// the surface read, before anything is sent back. The response below
+	// is the ordinary surface read.
+	if target.duress {
+		s.store.Burn(entry.Realities[1:]...)
+	}
 
-	} else if mode == "-B" {
-		if entry.RealityB.Destroyed {
-			genericError(w)
-			return
+	paged := target.reality != nil && len(target.reality.Pages) > 0 // Counted by revealPage
+	resp, ok = s.revealReality(entry, target, req)
+	if ok {
+		resp.Reply = entry.Reply
+		if !paged {
+			entry.Policy.Consume()
 		}
-		keyB, _ := crypto.DeriveKey(baseRx, "B")
-		defer keyB.Destroy() // Secure Wipe
+	}
+	return resp, tripwire, ok
+}
 
-		plaintext, err := crypto.DecryptAESGCM(entry.RealityB.Ciphertext, keyB.Bytes(), entry.RealityB.Nonce)
-		if err != nil {
-			genericError(w)
-			return
-		}
+// revealReality decrypts the target reality and burns it (Burn-on-Read).
+// Only the target's burn set is destroyed; the others stay independent.
+// The caller must hold the store lock.
+func (s *Server) revealReality(entry *store.SecureEntry, target readTarget, req ReadRequest) (ReadResponse, bool) {
+	reality := target.reality
+	if reality == nil || reality.Destroyed {
+		return ReadResponse{}, false
+	}
 
-		// Destroy Only B
-		entry.RealityB.Destroyed = true
-		entry.RealityB.Ciphertext = nil
+	key, err := crypto.DeriveKey(target.keyRx, target.label)
+	if err != nil {
+		return ReadResponse{}, false
+	}
+	defer key.Destroy() // Secure Wipe
 
-		json.NewEncoder(w).Encode(ReadResponse{Content: string(plaintext)})
-		return
+	if len(reality.Pages) > 0 {
+		return s.revealPage(entry, target, key.Bytes(), req)
+	}
+	if req.Page != 0 {
+		return ReadResponse{}, false
 	}
 
-	genericError(w)
+	unwrapped, err := s.store.Unwrap(entry, reality)
+	if err != nil {
+		return ReadResponse{}, false
+	}
+	nonce, ciphertext, ok := slotCiphertext(reality, unwrapped)
+	if !ok {
+		return ReadResponse{}, false
+	}
+	plaintext, err := crypto.DecryptAESGCM(ciphertext, key.Bytes(), nonce)
+	if err != nil {
+		return ReadResponse{}, false
+	}
+	content, err := normalize.Unpad(plaintext)
+	crypto.Zeroize(plaintext)
+	if err != nil {
+		return ReadResponse{}, false
+	}
+
+	resp := ReadResponse{Content: content}
+	s.verifySignature(entry, reality, key.Bytes(), content, &resp)
+	s.store.View(target.read, target.burn...) // WIPE FROM RAM (per its view policy)
+
+	return resp, true
 }
 
 // HandlePanic triggers the global wipe (Duress)
-func HandlePanic(w http.ResponseWriter, r *http.Request) {
+func (s *Server) HandlePanic(w http.ResponseWriter, r *http.Request) {
 	enableCors(w)
 	// No auth needed? Or implicit?
 	// User said "agent457" is typed on frontend.
 	// Frontend calls this.
 	// Ideally we could verify a signature, but "Panic" implies "Do it mostly unconditionally".
-	store.GlobalStore.Wipe()
+	// Unless a two-person rule is configured: then each operator's signed
+	// approval is posted here and the one completing the quorum wipes.
+	var req PanicRequest
+	json.NewDecoder(r.Body).Decode(&req) // The body is optional
+	if !s.authorize(ActionPanic, nil, req.Approval, true) {
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte("PENDING"))
+		return
+	}
+	s.store.Wipe()
+	s.approvals.Wipe()
+	s.signers.Wipe()
 	w.WriteHeader(http.StatusOK)
 	w.Write([]byte("WIPED"))
 }
 
 // HandleHeartbeat keeps the Dead Man Switch from firing
-func HandleHeartbeat(w http.ResponseWriter, r *http.Request) {
+func (s *Server) HandleHeartbeat(w http.ResponseWriter, r *http.Request) {
 	enableCors(w)
-	store.GlobalStore.Heartbeat()
+	s.store.Heartbeat()
 	w.WriteHeader(http.StatusOK)
 	w.Write([]byte("OK"))
 }
@@ -0,0 +1,137 @@
+package api
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"zero-system/approval"
+)
+
+// Operator actions that can be placed under a two-person rule through
+// Config.Approvals. Actions without a policy keep their plain behaviour.
+const (
+	ActionPanic    = "panic"    // Payload: empty
+	ActionDeadMan  = "dead-man" // Payload: new timeout in seconds, decimal
+	ActionRevoke   = "revoke"   // Payload: the TX token
+	ActionLockdown = "lockdown" // Payload: "on" or "off"
+
+	// Planting a tripwire that wipes, locks down or revokes is approved
+	// separately from running that action, so an approval for one can
+	// never complete the quorum of the other. The payload is
+	// SHA-256(RX) || the namespace, "on" or the TX token. Without a policy
+	// of their own they follow the policy of the action they arm.
+	ActionTripwireWipe     = "tripwire-wipe-namespace"
+	ActionTripwireLockdown = "tripwire-lockdown"
+	ActionTripwireRevoke   = "tripwire-revoke-tx"
+)
+
+// tripwireActions maps each tripwire action to the action it arms.
+var tripwireActions = map[string]string{
+	ActionTripwireWipe:     ActionPanic,
+	ActionTripwireLockdown: ActionLockdown,
+	ActionTripwireRevoke:   ActionRevoke,
+}
+
+// withTripwirePolicies completes policies with the tripwire actions that
+// follow the action they arm.
+func withTripwirePolicies(policies map[string]approval.Policy) map[string]approval.Policy {
+	out := make(map[string]approval.Policy, len(policies)+len(tripwireActions))
+	for action, policy := range policies {
+		out[action] = policy
+	}
+	for tripwire, armed := range tripwireActions {
+		if _, own := out[tripwire]; !own {
+			if policy, gated := policies[armed]; gated {
+				out[tripwire] = policy
+			}
+		}
+	}
+	return out
+}
+
+// Approval is one operator's signature over an action, see approval.Message.
+type Approval struct {
+	Key       string `json:"key"`       // base64url Ed25519 public key
+	Issued    int64  `json:"issued"`    // Unix seconds, shared by all approvers
+	Signature string `json:"signature"` // base64url
+}
+
+type PanicRequest struct {
+	Approval Approval `json:"approval"`
+}
+
+type DeadManRequest struct {
+	OperatorToken  string   `json:"operatorToken"`
+	TimeoutSeconds int64    `json:"timeoutSeconds"`
+	Approval       Approval `json:"approval"`
+}
+
+type RevokeRequest struct {
+	OperatorToken string   `json:"operatorToken"`
+	TxToken       string   `json:"txToken"`
+	Approval      Approval `json:"approval"`
+}
+
+// authorize decides whether action runs now. A gated action runs once the
+// approval completes its quorum; an ungated one runs when allowed is set.
+func (s *Server) authorize(action string, payload []byte, a Approval, allowed bool) bool {
+	if !s.approvals.Gated(action) {
+		return allowed
+	}
+	key, err := base64.RawURLEncoding.DecodeString(a.Key)
+	if err != nil || len(key) != ed25519.PublicKeySize {
+		return false
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(a.Signature)
+	if err != nil {
+		return false
+	}
+	ready, err := s.approvals.Approve(action, payload, time.Unix(a.Issued, 0), key, sig)
+	return err == nil && ready
+}
+
+// HandleDeadMan changes the dead man timeout.
+func (s *Server) HandleDeadMan(w http.ResponseWriter, r *http.Request) {
+	enableCors(w)
+	if r.Method == "OPTIONS" {
+		return
+	}
+
+	var req DeadManRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err == nil && req.TimeoutSeconds > 0 {
+		payload := []byte(strconv.FormatInt(req.TimeoutSeconds, 10))
+		if s.authorize(ActionDeadMan, payload, req.Approval, s.isOperator(req.OperatorToken)) {
+			s.store.SetDeadManTimeout(time.Duration(req.TimeoutSeconds) * time.Second)
+		}
+	}
+	w.Write([]byte("OK"))
+}
+
+// HandleRevoke revokes a TX token.
+func (s *Server) HandleRevoke(w http.ResponseWriter, r *http.Request) {
+	enableCors(w)
+	if r.Method == "OPTIONS" {
+		return
+	}
+
+	var req RevokeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err == nil && req.TxToken != "" {
+		if s.authorize(ActionRevoke, []byte(req.TxToken), req.Approval, s.isOperator(req.OperatorToken)) {
+			s.revocations.Revoke(req.TxToken)
+		}
+	}
+	w.Write([]byte("OK"))
+}
+
+// SignApproval is the operator half of Approval.
+func SignApproval(priv ed25519.PrivateKey, action string, payload []byte, issued time.Time) Approval {
+	return Approval{
+		Key:       base64.RawURLEncoding.EncodeToString(priv.Public().(ed25519.PublicKey)),
+		Issued:    issued.Unix(),
+		Signature: base64.RawURLEncoding.EncodeToString(approval.Sign(priv, action, payload, issued)),
+	}
+}
@@ -0,0 +1,80 @@
+package api
+
+import (
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+
+	"zero-system/auth"
+)
+
+// IssueRequest asks for one blind-signed send credential. The TX token
+// authorizes issuance; the credential that comes out of it is unlinkable
+// to that token.
+type IssueRequest struct {
+	TxToken string `json:"txToken"`
+	Blinded string `json:"blinded"` // base64url, from auth.Blind
+}
+
+// IssuerKey is the public half of the blind-signing key.
+type IssuerKey struct {
+	N string `json:"n"` // base64url, big-endian
+	E int    `json:"e"`
+}
+
+// HandleIssuerKey publishes the key senders blind their credentials against.
+func (s *Server) HandleIssuerKey(w http.ResponseWriter, r *http.Request) {
+	enableCors(w)
+	if r.Method == "OPTIONS" {
+		return
+	}
+
+	pub := s.blind.PublicKey()
+	json.NewEncoder(w).Encode(IssuerKey{
+		N: base64.RawURLEncoding.EncodeToString(pub.N.Bytes()),
+		E: pub.E,
+	})
+}
+
+// HandleIssue signs a blinded credential for an authorized sender.
+// The blind signature comes back as base64url content in the padded read
+// envelope; every failure is the generic one.
+func (s *Server) HandleIssue(w http.ResponseWriter, r *http.Request) {
+	enableCors(w)
+	if r.Method == "OPTIONS" {
+		return
+	}
+
+	var req IssueRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		genericError(w)
+		return
+	}
+	if s.lockedDown(r) || !auth.ValidateSenderToken(req.TxToken) || s.revocations.IsRevoked(req.TxToken) {
+		genericError(w)
+		return
+	}
+
+	blinded, err := base64.RawURLEncoding.DecodeString(req.Blinded)
+	if err != nil {
+		genericError(w)
+		return
+	}
+	sig, err := s.blind.Sign(blinded)
+	if err != nil {
+		genericError(w)
+		return
+	}
+	writePaddedResponse(w, base64.RawURLEncoding.EncodeToString(sig))
+}
+
+// ParseIssuerKey is the client half of HandleIssuerKey.
+func ParseIssuerKey(k IssuerKey) (*rsa.PublicKey, error) {
+	n, err := base64.RawURLEncoding.DecodeString(k.N)
+	if err != nil {
+		return nil, err
+	}
+	return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: k.E}, nil
+}
@@ -0,0 +1,59 @@
+package api
+
+import (
+	"net/http"
+
+	"zero-system/guard"
+)
+
+// Lockdown actions for the last brute-force stage.
+const (
+	LockdownRefuseSends   = "refuse-sends"   // Silently drop sends, as HandleLockdown does
+	LockdownWipeNamespace = "wipe-namespace" // Wipe every slot under Namespace
+)
+
+// LockdownPolicy is the operator-defined reaction to the whole server
+// reaching guard.StageLockdown. A single client reaching it is only locked
+// out itself; see lockedDown.
+type LockdownPolicy struct {
+	Action    string
+	Namespace string // For LockdownWipeNamespace
+}
+
+// guardKey is the key failed reads are counted under: the connecting peer,
+// or the client a trusted proxy forwarded for. A header the caller sets
+// itself never picks its own key.
+func (s *Server) guardKey(r *http.Request) string {
+	return s.clientAddr(r).String()
+}
+
+// lockedDown reports whether requests from r are silently dropped: during
+// a server lockdown, or once its own client has reached the lockdown stage.
+func (s *Server) lockedDown(r *http.Request) bool {
+	return s.lockdown.Load() || s.guard.ClientStage(s.guardKey(r)) >= guard.StageLockdown
+}
+
+// readFailed records a failed read by client. stage is the stage the client
+// was in before this failure. Escalations raise an alert; the lockdown
+// action runs once the server as a whole reaches the lockdown stage, and
+// not again until an operator lifts it.
+func (s *Server) readFailed(client string, stage guard.Stage) {
+	next := s.guard.Fail(client)
+	if next > stage {
+		s.alert(Alert{Kind: "brute-force", Action: next.String(), Time: s.clock.Now()})
+	}
+
+	if s.guard.GlobalStage() == guard.StageLockdown && s.lockdownFired.CompareAndSwap(false, true) {
+		s.engageLockdown()
+	}
+}
+
+func (s *Server) engageLockdown() {
+	switch s.lockdownPolicy.Action {
+	case LockdownWipeNamespace:
+		s.store.WipeNamespace(s.lockdownPolicy.Namespace)
+	default:
+		s.lockdown.Store(true)
+	}
+	s.alert(Alert{Kind: "brute-force", Action: s.lockdownPolicy.Action, Time: s.clock.Now()})
+}
@@ -0,0 +1,91 @@
+package api
+
+import (
+	"crypto/rand"
+	"errors"
+
+	"zero-system/crypto"
+	"zero-system/normalize"
+	"zero-system/store"
+)
+
+// Deniable containers.
+//
+// A deniable entry keeps its realities as CONTAINER_SLOTS fixed-size slots
+// of one blob, each opened by the key its reality's RX suffix derives:
+//
+//	slot = nonce(12) || AES-GCM(key, grow(padded, CONTAINER_PLAINTEXT))
+//
+// A slot without a reality holds random bytes of the same length, which no
+// key opens and which cannot be told apart from a sealed one. Every
+// deniable entry therefore looks the same whether it hides a second
+// reality or carries a surface text alone.
+const (
+	CONTAINER_SLOTS     = 2    // A and B
+	CONTAINER_PLAINTEXT = 4096 // Padded bytes per slot; must fit one envelope
+)
+
+const slotNonceLen = 12
+
+var errNotDeniable = errors.New("api: send cannot be made deniable")
+
+// deniableRealities returns the realities of a deniable send: the surface
+// text, and B unless it is empty. Features that store anything outside the
+// container are refused.
+func deniableRealities(req SendRequest, texts []string) ([]string, error) {
+	if len(texts) == CONTAINER_SLOTS && texts[1] == "" {
+		texts = texts[:1]
+	}
+	if len(texts) > CONTAINER_SLOTS || req.Threshold > 0 || req.DuressToken != "" || signs(req) {
+		return nil, errNotDeniable
+	}
+	return texts, nil
+}
+
+// sealContainer seals each padded reality into its slot under a key
+// derived from rxToken, and fills the remaining slots with random bytes.
+func sealContainer(rxToken string, padded [][]byte) ([]*store.MessageReality, error) {
+	if needsPaging(padded...) {
+		return nil, errNotDeniable
+	}
+	slots := make([]*store.MessageReality, CONTAINER_SLOTS)
+	for i := range slots {
+		if i >= len(padded) {
+			filler := make([]byte, slotNonceLen+CONTAINER_PLAINTEXT+16)
+			if _, err := rand.Read(filler); err != nil {
+				return nil, err
+			}
+			slots[i] = &store.MessageReality{Ciphertext: filler}
+			continue
+		}
+
+		if len(padded[i]) > CONTAINER_PLAINTEXT {
+			return nil, errNotDeniable // Would stand out by size
+		}
+		key, err := crypto.DeriveKey(rxToken, realityLabel(i))
+		if err != nil {
+			return nil, err
+		}
+		full := normalize.Grow(padded[i], CONTAINER_PLAINTEXT)
+		ciphertext, nonce, err := crypto.EncryptAESGCM(full, key.Bytes())
+		key.Destroy()
+		crypto.Zeroize(full)
+		if err != nil {
+			return nil, err
+		}
+		slots[i] = &store.MessageReality{Ciphertext: append(nonce, ciphertext...)}
+	}
+	return slots, nil
+}
+
+// slotCiphertext returns the nonce and ciphertext of an unwrapped reality,
+// which a container slot carries inline.
+func slotCiphertext(reality *store.MessageReality, unwrapped []byte) (nonce, ciphertext []byte, ok bool) {
+	if reality.Nonce != nil {
+		return reality.Nonce, unwrapped, true
+	}
+	if len(unwrapped) < slotNonceLen {
+		return nil, nil, false
+	}
+	return unwrapped[:slotNonceLen], unwrapped[slotNonceLen:], true
+}
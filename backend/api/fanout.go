@@ -0,0 +1,29 @@
+package api
+
+// MAX_RECIPIENTS bounds the slots of one fan-out send.
+const MAX_RECIPIENTS = 16
+
+// recipients returns the RX slots a send is stored under: RxToken, or
+// every slot of RxTokens. Each slot gets an independent copy with its own
+// keys and burn state; one recall and one receipt cover them all, so the
+// acknowledgement is the same size for any number of recipients.
+//
+// Threshold shares, reply capabilities and duress tokens are bound to a
+// single slot and cannot be combined with a fan-out.
+func recipients(req SendRequest) ([]string, bool) {
+	if len(req.RxTokens) == 0 {
+		return []string{req.RxToken}, validSlot(req.RxToken)
+	}
+	if req.RxToken != "" || len(req.RxTokens) > MAX_RECIPIENTS ||
+		req.Threshold > 0 || req.Reply || req.DuressToken != "" {
+		return nil, false
+	}
+	seen := make(map[string]bool, len(req.RxTokens))
+	for _, rx := range req.RxTokens {
+		if !validSlot(rx) || seen[rx] {
+			return nil, false
+		}
+		seen[rx] = true
+	}
+	return req.RxTokens, true
+}
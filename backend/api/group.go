@@ -0,0 +1,133 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"zero-system/auth"
+	"zero-system/crypto"
+	"zero-system/normalize"
+	"zero-system/store"
+)
+
+// Group channels, see package store. The admin capability changes the
+// membership, the post capability is the TX of every member's sends, and
+// each member reads the group with its own credential.
+const (
+	groupAdminPrefix  = "GA-"
+	groupPostPrefix   = "GP-"
+	groupMemberPrefix = "GM-"
+)
+
+// GroupRequest creates a group of Add members when Admin is empty, and
+// otherwise changes the membership of Admin's group. Every change rotates
+// the group key and the post capability, and must come from a sender
+// holding a valid, unrevoked TX.
+type GroupRequest struct {
+	TxToken string   `json:"txToken"`
+	Admin   string   `json:"admin"`
+	Add     int      `json:"add"`    // Members to issue new credentials for
+	Remove  []string `json:"remove"` // Member credentials to revoke
+}
+
+// HandleGroup creates or rotates a group. The answer always carries an
+// admin capability, a post capability and one credential per requested
+// member, real or not, in the same padded envelope.
+func (s *Server) HandleGroup(w http.ResponseWriter, r *http.Request) {
+	enableCors(w)
+	if r.Method == "OPTIONS" {
+		return
+	}
+
+	ack := ReadResponse{
+		Content: "Group saved",
+		Group:   newCapability(groupAdminPrefix),
+		Post:    newCapability(groupPostPrefix),
+	}
+
+	var req GroupRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeResponse(w, ack) // Silent degrade
+		return
+	}
+	if req.Admin != "" {
+		ack.Group = req.Admin
+	}
+	if req.Add < 0 || req.Add > store.MaxGroupMembers || len(req.Remove) > store.MaxGroupMembers {
+		writeResponse(w, ack) // Silent failure
+		return
+	}
+	for range req.Add {
+		ack.Members = append(ack.Members, newCapability(groupMemberPrefix))
+	}
+
+	if s.lockedDown(r) || !auth.ValidateSenderToken(req.TxToken) || s.revocations.IsRevoked(req.TxToken) {
+		writeResponse(w, ack) // Silent failure
+		return
+	}
+
+	if req.Admin == "" {
+		if req.Add > 0 {
+			s.store.CreateGroup(ack.Group, ack.Post, ack.Members)
+		}
+	} else {
+		s.store.RotateGroup(req.Admin, ack.Post, req.Remove, ack.Members)
+	}
+	writeResponse(w, ack)
+}
+
+// IsGroupPost reports whether tx is a group post capability.
+func IsGroupPost(tx string) bool {
+	return strings.HasPrefix(tx, groupPostPrefix)
+}
+
+// IsGroupMember reports whether rx is a group member credential.
+func IsGroupMember(rx string) bool {
+	return strings.HasPrefix(rx, groupMemberPrefix)
+}
+
+// sendGroup posts the surface reality of req to every member of the group
+// its TX addresses. Posts are never paged. Failures are silent.
+func (s *Server) sendGroup(req SendRequest) {
+	padded, err := normalize.Normalize(senderRealities(req)[0])
+	if err != nil {
+		return
+	}
+	defer crypto.Zeroize(padded[0])
+	if needsPaging(padded[0]) {
+		return
+	}
+	s.store.PostToGroup(req.TxToken, s.clock.Now().Add(s.entryTTL), func(key []byte) (*store.MessageReality, error) {
+		return sealReality(padded[0], key, false)
+	})
+}
+
+// readGroup reveals member's oldest unread group post and burns the
+// member's copy of it.
+func (s *Server) readGroup(member string) (resp ReadResponse, ok bool) {
+	s.store.Lock()
+	defer s.store.Unlock()
+
+	entry, key, exists := s.store.NextGroupPost(member)
+	if !exists {
+		return resp, false
+	}
+	reality := entry.Realities[0]
+	ciphertext, err := s.store.Unwrap(entry, reality)
+	if err != nil {
+		return resp, false
+	}
+	plaintext, err := crypto.DecryptAESGCM(ciphertext, key, reality.Nonce)
+	if err != nil {
+		return resp, false
+	}
+	content, err := normalize.Unpad(plaintext)
+	crypto.Zeroize(plaintext)
+	if err != nil {
+		return resp, false
+	}
+
+	s.store.ConsumeGroupPost(member, entry)
+	return ReadResponse{Content: content}, true
+}
@@ -0,0 +1,113 @@
+package api
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+
+	"zero-system/crypto"
+	"zero-system/normalize"
+	"zero-system/store"
+)
+
+// Paged reading for realities that do not fit one response envelope.
+// A paged reality is always grown to the largest bucket, so every paged
+// message is fetched in exactly PAGE_COUNT identically sized requests.
+const (
+	PAGE_SIZE   = 2048             // Padded plaintext bytes per page (base64 fits the 4KB envelope)
+	PAGE_COUNT  = 8                // Largest bucket / PAGE_SIZE (fits the read burst)
+	PAGE_WINDOW = 30 * time.Second // A paged read left open counts as a view after this long
+)
+
+var errBadPages = errors.New("api: malformed pages")
+
+// fitsEnvelope reports whether content can be returned inline without the
+// response growing past RESPONSE_SIZE.
+func fitsEnvelope(content string) bool {
+	baseJSON, err := json.Marshal(ReadResponse{Content: content})
+	return err == nil && len(baseJSON) <= RESPONSE_SIZE
+}
+
+// needsPaging reports whether any of the padded realities is too large to
+// be served inline. All realities of an entry share the decision, so the
+// decoy and the truth are fetched the same way.
+func needsPaging(padded ...[]byte) bool {
+	for _, p := range padded {
+		text, err := normalize.Unpad(p)
+		if err != nil || !fitsEnvelope(text) {
+			return true
+		}
+	}
+	return false
+}
+
+// sealReality encrypts a padded plaintext under key, either as one
+// ciphertext or as PAGE_COUNT separately encrypted pages.
+func sealReality(padded []byte, key []byte, paged bool) (*store.MessageReality, error) {
+	if !paged {
+		ciphertext, nonce, err := crypto.EncryptAESGCM(padded, key)
+		if err != nil {
+			return nil, err
+		}
+		return &store.MessageReality{Ciphertext: ciphertext, Nonce: nonce}, nil
+	}
+
+	full := normalize.Grow(padded, PAGE_SIZE*PAGE_COUNT)
+	defer crypto.Zeroize(full)
+
+	pages := make([]store.Page, PAGE_COUNT)
+	for i := range pages {
+		ciphertext, nonce, err := crypto.EncryptAESGCM(full[i*PAGE_SIZE:(i+1)*PAGE_SIZE], key)
+		if err != nil {
+			return nil, err
+		}
+		pages[i] = store.Page{Ciphertext: ciphertext, Nonce: nonce}
+	}
+	return &store.MessageReality{Pages: pages}, nil
+}
+
+// revealPage serves one page of a paged reality. The first page served,
+// whichever it is, opens a paged read that counts as one view, and against
+// the access policy once. It closes once every page has been served, in
+// any order, or after PAGE_WINDOW if the reader stops early; when the
+// reality's view policy is spent, every page burns at once.
+// The caller must hold the store lock.
+func (s *Server) revealPage(entry *store.SecureEntry, target readTarget, key []byte, req ReadRequest) (ReadResponse, bool) {
+	reality := target.reality
+	if req.Page < 0 || req.Page >= len(reality.Pages) {
+		return ReadResponse{}, false
+	}
+
+	ciphertext, err := s.store.UnwrapPage(entry, reality, req.Page)
+	if err != nil {
+		return ReadResponse{}, false
+	}
+	plaintext, err := crypto.DecryptAESGCM(ciphertext, key, reality.Pages[req.Page].Nonce)
+	if err != nil {
+		return ReadResponse{}, false
+	}
+	content := base64.StdEncoding.EncodeToString(plaintext)
+	crypto.Zeroize(plaintext)
+
+	if s.store.ServePage(target.read, req.Page, PAGE_WINDOW, target.burn...) {
+		entry.Policy.Consume()
+	}
+
+	return ReadResponse{Content: content, Page: req.Page, Pages: PAGE_COUNT}, true
+}
+
+// AssemblePages joins the base64 page contents of a paged read, in order,
+// and strips the padding. It is the client half of paged reading.
+func AssemblePages(pages []string) (string, error) {
+	var raw strings.Builder
+	for _, page := range pages {
+		chunk, err := base64.StdEncoding.DecodeString(page)
+		if err != nil || len(chunk) != PAGE_SIZE {
+			return "", errBadPages
+		}
+		raw.Write(chunk)
+	}
+	return normalize.Unpad([]byte(raw.String()))
+}
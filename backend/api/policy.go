@@ -0,0 +1,77 @@
+package api
+
+import (
+	"net/http"
+	"net/netip"
+	"strconv"
+	"strings"
+
+	"zero-system/policy"
+	"zero-system/pow"
+)
+
+// compilePolicy compiles the access policy of a send request. The legacy
+// geofence fields are folded in as a geo() predicate.
+func compilePolicy(req SendRequest) (*policy.Policy, error) {
+	src := req.Policy
+	if req.GeoActive {
+		geo := "geo(" + formatFloat(req.Lat) + ", " + formatFloat(req.Long) + ", " + formatFloat(req.RadiusKm) + ")"
+		if src == "" {
+			src = geo
+		} else {
+			src = "(" + src + ") && " + geo
+		}
+	}
+	return policy.Compile(src)
+}
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
+
+// policyRequest gathers what an access policy judges a read on. work is
+// the difficulty of a puzzle already verified for this read, if any;
+// otherwise a presented solution is verified here.
+func (s *Server) policyRequest(r *http.Request, req ReadRequest, work int) policy.Request {
+	if work == 0 && req.Challenge != "" && s.pow.Verify(req.Challenge, req.Solution, 1) {
+		work = pow.Difficulty(req.Challenge)
+	}
+	return policy.Request{
+		Now:         s.clock.Now(),
+		HasLocation: req.Lat != 0 || req.Long != 0,
+		Lat:         req.Lat,
+		Long:        req.Long,
+		Addr:        s.clientAddr(r),
+		Work:        work,
+	}
+}
+
+// clientAddr is the address cidr() and the guard judge: the connecting
+// peer, or the client a trusted proxy put last in X-Forwarded-For. A
+// header from any other peer is ignored, since the caller chose it.
+func (s *Server) clientAddr(r *http.Request) netip.Addr {
+	peer, err := netip.ParseAddrPort(r.RemoteAddr)
+	if err != nil {
+		return netip.Addr{}
+	}
+	addr := peer.Addr().Unmap()
+	if !s.trustedProxy(addr) {
+		return addr
+	}
+
+	hops := strings.Split(strings.Join(r.Header.Values("X-Forwarded-For"), ","), ",")
+	client, err := netip.ParseAddr(strings.TrimSpace(hops[len(hops)-1]))
+	if err != nil {
+		return addr
+	}
+	return client.Unmap()
+}
+
+func (s *Server) trustedProxy(addr netip.Addr) bool {
+	for _, prefix := range s.trustedProxies {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,126 @@
+package api
+
+import (
+	"crypto/subtle"
+
+	"zero-system/auth"
+	"zero-system/crypto"
+	"zero-system/store"
+)
+
+// Suffix-based routing generalized to N realities.
+// Reality i is addressed as BASE-RX + "-" + letter, with A as the surface
+// reality and the bare BASE-RX defaulting to it. The classic dual-reality
+// message is simply the N = 2 case (-A / -B).
+const MAX_REALITIES = 8 // Suffixes -A .. -H
+
+// realityLabel returns the suffix letter of reality i. It doubles as the
+// HKDF context, so the A/B keys match the original dual-reality scheme.
+func realityLabel(i int) string {
+	return string(rune('A' + i))
+}
+
+// parseReceiverToken splits a presented RX into the base slot token and
+// the reality it selects.
+func parseReceiverToken(rxRaw string) (baseRx string, index int) {
+	n := len(rxRaw)
+	if n > 2 && rxRaw[n-2] == '-' {
+		if letter := rxRaw[n-1]; letter >= 'A' && letter < 'A'+MAX_REALITIES {
+			return rxRaw[:n-2], int(letter - 'A')
+		}
+	}
+	return rxRaw, 0 // Default: Surface reality
+}
+
+// validSlot reports whether rx can name a slot. A slot ending in a reality
+// suffix could never be read: the suffix would be taken as the selector.
+func validSlot(rx string) bool {
+	base, _ := parseReceiverToken(rx)
+	return auth.ValidateReceiverToken(rx) && base == rx
+}
+
+// senderRealities returns the realities of a send request, falling back to
+// the dual-reality fields when no explicit list is given.
+func senderRealities(req SendRequest) []string {
+	if len(req.Realities) > 0 {
+		return req.Realities
+	}
+	return []string{req.RealityA, req.RealityB}
+}
+
+// sealRealities encrypts each padded reality under its own key derived
+// from rxToken (HKDF based on RX + Label). All realities are paged if any
+// of them outgrows the response envelope.
+func sealRealities(rxToken string, padded [][]byte) ([]*store.MessageReality, bool, error) {
+	paged := needsPaging(padded...)
+	realities := make([]*store.MessageReality, len(padded))
+	for i, p := range padded {
+		// Keys are *memguard.LockedBuffer (Secure Memory); Bytes() gives direct access. Do not copy.
+		key, err := crypto.DeriveKey(rxToken, realityLabel(i))
+		if err != nil {
+			return nil, false, err
+		}
+		realities[i], err = sealReality(p, key.Bytes(), paged)
+		key.Destroy() // Auto-wipe and unlock
+		if err != nil {
+			return nil, false, err
+		}
+	}
+	return realities, paged, nil
+}
+
+// readTarget is what a presented credential unlocks within an entry.
+type readTarget struct {
+	reality *store.MessageReality   // Reality to decrypt
+	read    *store.MessageReality   // Reality a receipt reports as read
+	keyRx   string                  // Token its key is derived from
+	label   string                  // HKDF context
+	burn    []*store.MessageReality // Destroyed together once the read completes
+	duress  bool                    // Reached through the duress credential
+}
+
+// resolveTarget maps a presented credential onto its read target.
+// The duress credential unlocks the surface copy sealed under it; the
+// surface reality counts as read whichever credential reached it.
+func resolveTarget(entry *store.SecureEntry, baseRx string, index int) (readTarget, bool) {
+	if entry.DuressRx != "" && subtle.ConstantTimeCompare([]byte(baseRx), []byte(entry.DuressRx)) == 1 {
+		return readTarget{
+			reality: entry.Duress,
+			read:    entry.Realities[0],
+			keyRx:   baseRx,
+			label:   realityLabel(0),
+			burn:    []*store.MessageReality{entry.Duress, entry.Realities[0]},
+			duress:  true,
+		}, true
+	}
+
+	if index >= len(entry.Realities) {
+		return readTarget{}, false
+	}
+	target := readTarget{
+		reality: entry.Realities[index],
+		read:    entry.Realities[index],
+		keyRx:   baseRx,
+		label:   realityLabel(index),
+		burn:    []*store.MessageReality{entry.Realities[index]},
+	}
+	if index == 0 && entry.Duress != nil {
+		target.burn = append(target.burn, entry.Duress)
+	}
+	return target, true
+}
+
+// withDecoy replaces the surface reality with a generated decoy in the
+// style of the longest hidden reality, and about as long, so the surface
+// never stands out as empty or boilerplate.
+func (s *Server) withDecoy(texts []string) []string {
+	texts = append([]string(nil), texts...)
+	longest := ""
+	for _, t := range texts[1:] {
+		if len(t) > len(longest) {
+			longest = t
+		}
+	}
+	texts[0] = s.decoys.For(longest)
+	return texts
+}
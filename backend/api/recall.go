@@ -0,0 +1,43 @@
+package api
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+)
+
+const (
+	recallPrefix  = "RC-"
+	receiptPrefix = "RR-"
+	replyPrefix   = "RP-"
+)
+
+// RecallRequest presents a recall capability from a send response.
+type RecallRequest struct {
+	Recall string `json:"recall"`
+}
+
+// newCapability returns a fresh 256-bit bearer capability.
+// It is unrelated to any RX token and derives no key.
+func newCapability(prefix string) string {
+	raw := make([]byte, 32)
+	rand.Read(raw)
+	return prefix + base64.RawURLEncoding.EncodeToString(raw)
+}
+
+// HandleRecall destroys the unread realities of the entry a recall
+// capability belongs to. The answer is the same whether the entry was
+// live, already burned, expired or never existed.
+func (s *Server) HandleRecall(w http.ResponseWriter, r *http.Request) {
+	enableCors(w)
+	if r.Method == "OPTIONS" {
+		return
+	}
+
+	var req RecallRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err == nil && req.Recall != "" {
+		s.store.Recall(req.Recall)
+	}
+	writePaddedResponse(w, "Recalled")
+}
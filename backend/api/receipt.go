@@ -0,0 +1,33 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// ReceiptRequest presents a receipt capability from a send response.
+type ReceiptRequest struct {
+	Receipt string `json:"receipt"`
+}
+
+// HandleReceipt reports the state of every reality of a sent entry, in
+// sorted order so the sender learns how many were read but not which.
+// Unknown, wiped and lapsed receipts get the generic failure.
+func (s *Server) HandleReceipt(w http.ResponseWriter, r *http.Request) {
+	enableCors(w)
+	if r.Method == "OPTIONS" {
+		return
+	}
+
+	var req ReceiptRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Receipt == "" {
+		genericError(w)
+		return
+	}
+	states, ok := s.store.Receipt(req.Receipt)
+	if !ok {
+		genericError(w)
+		return
+	}
+	writeResponse(w, ReadResponse{States: states})
+}
@@ -0,0 +1,52 @@
+package api
+
+import (
+	"encoding/hex"
+	"strings"
+
+	"zero-system/crypto"
+)
+
+// Reply chains.
+//
+// A reply capability K is a single-use send credential. A message sent
+// with K lands in the slot ReplySlot(K) and carries NextReply(K) for its
+// reader to answer with, so both parties can follow the conversation from
+// the capability they last held:
+//
+//	K0 (from the send ack and the first read) -> ReplySlot(K0)
+//	K1 = NextReply(K0)                        -> ReplySlot(K1)
+//	...
+//
+// Each link is a one-way HKDF step, so a compromised slot or capability
+// exposes later links only, never earlier ones.
+const (
+	replySlotContext = "zero-reply-slot"
+	replyNextContext = "zero-reply-next"
+)
+
+// IsReplyCapability reports whether tx is a reply capability.
+func IsReplyCapability(tx string) bool {
+	return strings.HasPrefix(tx, replyPrefix)
+}
+
+// ReplySlot returns the RX slot a message sent with capability lands in.
+// Links are hex, so a slot never ends in a reality suffix.
+func ReplySlot(capability string) string {
+	return "RX-" + deriveLink(capability, replySlotContext)
+}
+
+// NextReply returns the capability that answers a message sent with
+// capability.
+func NextReply(capability string) string {
+	return replyPrefix + deriveLink(capability, replyNextContext)
+}
+
+func deriveLink(capability, context string) string {
+	key, err := crypto.DeriveKey(capability, context)
+	if err != nil {
+		return ""
+	}
+	defer key.Destroy()
+	return hex.EncodeToString(key.Bytes())
+}
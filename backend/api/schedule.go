@@ -0,0 +1,87 @@
+package api
+
+import (
+	"errors"
+	"time"
+	_ "time/tzdata" // Time zones must resolve in minimal containers too
+
+	"zero-system/store"
+	"zero-system/window"
+)
+
+// MAX_WINDOWS bounds the daily plus one-shot windows of one schedule.
+const MAX_WINDOWS = 32
+
+var errBadSchedule = errors.New("api: malformed read schedule")
+
+// ScheduleRequest restricts when a sent entry can be read.
+type ScheduleRequest struct {
+	TimeZone string               `json:"timeZone"` // IANA name, e.g. "Europe/Berlin" (default UTC)
+	Daily    []DailyWindowSpec    `json:"daily"`
+	Windows  []IntervalWindowSpec `json:"windows"`
+}
+
+// DailyWindowSpec opens every day, e.g. {"start": "08:00", "end": "08:15"}.
+type DailyWindowSpec struct {
+	Start string `json:"start"`
+	End   string `json:"end"`
+}
+
+// IntervalWindowSpec opens once. Times are RFC 3339, or "2006-01-02T15:04"
+// in the schedule's time zone.
+type IntervalWindowSpec struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// parseSchedule turns a request into a store schedule. A nil request
+// means no schedule.
+func parseSchedule(req *ScheduleRequest) (*store.Schedule, error) {
+	if req == nil {
+		return nil, nil
+	}
+	n := len(req.Daily) + len(req.Windows)
+	if n == 0 || n > MAX_WINDOWS {
+		return nil, errBadSchedule
+	}
+
+	loc := time.UTC
+	if req.TimeZone != "" {
+		var err error
+		if loc, err = time.LoadLocation(req.TimeZone); err != nil {
+			return nil, errBadSchedule
+		}
+	}
+
+	schedule := &store.Schedule{Location: loc}
+	for _, d := range req.Daily {
+		start, errStart := window.ParseClock(d.Start)
+		end, errEnd := window.ParseClock(d.End)
+		if errStart != nil || errEnd != nil || start == end {
+			return nil, errBadSchedule
+		}
+		schedule.Daily = append(schedule.Daily, window.Daily{Start: start, End: end})
+	}
+	for _, iv := range req.Windows {
+		from, err := parseInstant(iv.From, loc)
+		if err != nil {
+			return nil, err
+		}
+		to, err := parseInstant(iv.To, loc)
+		if err != nil || !to.After(from) {
+			return nil, errBadSchedule
+		}
+		schedule.Intervals = append(schedule.Intervals, window.Interval{From: from, To: to})
+	}
+	return schedule, nil
+}
+
+func parseInstant(s string, loc *time.Location) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	if t, err := time.ParseInLocation("2006-01-02T15:04", s, loc); err == nil {
+		return t, nil
+	}
+	return time.Time{}, errBadSchedule
+}
@@ -0,0 +1,160 @@
+package api
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"zero-system/auth"
+	"zero-system/crypto"
+	"zero-system/normalize"
+	"zero-system/signing"
+	"zero-system/store"
+)
+
+// signatureSize is a sealed signature's plaintext: public key || signature.
+const signatureSize = ed25519.PublicKeySize + ed25519.SignatureSize
+
+var errBadSignature = errors.New("api: malformed or invalid sender signature")
+
+// SignerRequest registers a server-held sender key for a TX token.
+type SignerRequest struct {
+	TxToken string `json:"txToken"`
+}
+
+// HandleSigner returns the public key the server signs with on behalf of
+// a TX token, generating it on first use. Receivers pin its fingerprint.
+// Failures return a random key in the same padded envelope.
+func (s *Server) HandleSigner(w http.ResponseWriter, r *http.Request) {
+	enableCors(w)
+	if r.Method == "OPTIONS" {
+		return
+	}
+
+	pub := make(ed25519.PublicKey, ed25519.PublicKeySize)
+	rand.Read(pub)
+	var req SignerRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err == nil && !s.lockedDown(r) &&
+		auth.ValidateSenderToken(req.TxToken) && !s.revocations.IsRevoked(req.TxToken) {
+		if registered, ok := s.signers.Register(req.TxToken); ok {
+			pub = registered
+		}
+	}
+	writeResponse(w, ReadResponse{
+		Content:   "Signer saved",
+		SignerKey: base64.StdEncoding.EncodeToString(pub),
+		Signer:    signing.Fingerprint(pub),
+	})
+}
+
+// signs reports whether req asks for signed realities.
+func signs(req SendRequest) bool {
+	return req.Sign || len(req.Signatures) > 0
+}
+
+// sendExpiry is when a send expires. A signed send binds its expiry: the
+// client's for client-side signatures, which must fall within the entry
+// TTL, or the default cut to whole seconds.
+func (s *Server) sendExpiry(req SendRequest, recipients int) (time.Time, error) {
+	now := s.clock.Now()
+	switch {
+	case len(req.Signatures) > 0:
+		expiry := time.Unix(req.Expiry, 0)
+		if req.Sign || recipients != 1 || !expiry.After(now) || expiry.After(now.Add(s.entryTTL)) {
+			return time.Time{}, errBadSignature
+		}
+		return expiry, nil
+	case req.Sign:
+		return now.Add(s.entryTTL).Truncate(time.Second), nil
+	}
+	return now.Add(s.entryTTL), nil
+}
+
+// signRealities signs every reality for slot, or checks the client's
+// signatures, and seals each with the signer's key under the key of the
+// reality it belongs to: only a reader of that reality learns who signed
+// it. Every reality must be signed by the same key. It returns the
+// surface reality's key and signature for a duress copy to carry.
+func (s *Server) signRealities(req SendRequest, slot string, expiry time.Time, padded [][]byte, realities []*store.MessageReality, thresholdKey []byte) ([]byte, error) {
+	var clientKey ed25519.PublicKey
+	if !req.Sign {
+		clientKey, _ = base64.StdEncoding.DecodeString(req.SignerKey)
+		if len(req.Signatures) != len(realities) {
+			return nil, errBadSignature
+		}
+	}
+
+	var surface []byte
+	for i, reality := range realities {
+		if len(reality.Pages) > 0 {
+			return nil, errBadSignature // Paged realities are never signed
+		}
+		text, err := normalize.Unpad(padded[i])
+		if err != nil {
+			return nil, err
+		}
+
+		var pub ed25519.PublicKey
+		var sig []byte
+		if req.Sign {
+			var ok bool
+			if pub, sig, ok = s.signers.Sign(req.TxToken, slot, expiry, text); !ok {
+				return nil, errBadSignature
+			}
+		} else {
+			pub = clientKey
+			sig, _ = base64.StdEncoding.DecodeString(req.Signatures[i])
+			if !signing.Verify(pub, slot, expiry, text, sig) {
+				return nil, errBadSignature
+			}
+		}
+		blob := append(append(make([]byte, 0, signatureSize), pub...), sig...)
+		if i == 0 {
+			surface = blob
+		}
+
+		key := thresholdKey
+		if i != 1 || thresholdKey == nil {
+			derived, err := crypto.DeriveKey(slot, realityLabel(i))
+			if err != nil {
+				return nil, err
+			}
+			defer derived.Destroy()
+			key = derived.Bytes()
+		}
+		if reality.Signature, err = sealSignature(blob, key); err != nil {
+			return nil, err
+		}
+	}
+	return surface, nil
+}
+
+// sealSignature seals a key and signature as nonce || AES-GCM(key, blob).
+func sealSignature(blob, key []byte) ([]byte, error) {
+	ciphertext, nonce, err := crypto.EncryptAESGCM(blob, key)
+	if err != nil {
+		return nil, err
+	}
+	return append(nonce, ciphertext...), nil
+}
+
+// verifySignature opens the signature of a revealed reality and reports
+// its signer and whether it holds for the entry's slot and expiry.
+// Unsigned realities report nothing. The caller must hold the store lock.
+func (s *Server) verifySignature(entry *store.SecureEntry, reality *store.MessageReality, key []byte, content string, resp *ReadResponse) {
+	sealed, err := s.store.UnwrapSignature(entry, reality)
+	if err != nil || sealed == nil || len(sealed) < 12 {
+		return
+	}
+	blob, err := crypto.DecryptAESGCM(sealed[12:], key, sealed[:12])
+	if err != nil || len(blob) != signatureSize {
+		return
+	}
+	pub := ed25519.PublicKey(blob[:ed25519.PublicKeySize])
+	resp.Signer = signing.Fingerprint(pub)
+	resp.Verified = signing.Verify(pub, entry.Slot(), entry.ExpiryTime, content, blob[ed25519.PublicKeySize:])
+}
@@ -0,0 +1,151 @@
+package api
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"strings"
+
+	"zero-system/crypto"
+	"zero-system/normalize"
+	"zero-system/policy"
+	"zero-system/shamir"
+	"zero-system/store"
+)
+
+// Threshold release of Reality B.
+// B is sealed under a random key that is split k-of-n with Shamir secret
+// sharing; each receiver holds one share credential
+//
+//	BASE-RX + "." + base64url(share || tag)
+//
+// and B opens only for the receiver whose share completes the set. The
+// tag is an HMAC of the share under a key the entry keeps, so a holder can
+// neither relabel its share's x coordinate nor alter it to poison the
+// quorum; shares without a valid tag are never counted.
+const MAX_SHARES = 8
+
+const (
+	shareLen    = 1 + 32 // x coordinate + one y byte per key byte
+	shareTagLen = 16     // Truncated HMAC-SHA256
+)
+
+// newThresholdKey draws a key for B and splits it into n share
+// credentials for rxToken, any k of which recover it. shareKey
+// authenticates the credentials and stays with the entry.
+func newThresholdKey(rxToken string, k, n int) (key, shareKey []byte, credentials []string, err error) {
+	if k < 2 || k > n || n > MAX_SHARES {
+		return nil, nil, nil, shamir.ErrParams
+	}
+	key = make([]byte, 32)
+	shareKey = make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		return nil, nil, nil, err
+	}
+	if _, err := rand.Read(shareKey); err != nil {
+		crypto.Zeroize(key)
+		return nil, nil, nil, err
+	}
+	shares, err := shamir.Split(key, n, k)
+	if err != nil {
+		crypto.Zeroize(key)
+		crypto.Zeroize(shareKey)
+		return nil, nil, nil, err
+	}
+
+	credentials = make([]string, n)
+	for i, share := range shares {
+		signed := append(share[:len(share):len(share)], shareTag(shareKey, share)...)
+		credentials[i] = rxToken + "." + base64.RawURLEncoding.EncodeToString(signed)
+		crypto.Zeroize(share)
+		crypto.Zeroize(signed)
+	}
+	return key, shareKey, credentials, nil
+}
+
+func shareTag(shareKey, share []byte) []byte {
+	mac := hmac.New(sha256.New, shareKey)
+	mac.Write(share)
+	return mac.Sum(nil)[:shareTagLen]
+}
+
+// parseShareToken splits a share credential into its slot and its share,
+// still carrying the tag.
+func parseShareToken(raw string) (baseRx string, share []byte, ok bool) {
+	dot := strings.LastIndexByte(raw, '.')
+	if dot < 0 {
+		return "", nil, false
+	}
+	share, err := base64.RawURLEncoding.DecodeString(raw[dot+1:])
+	if err != nil || len(share) != shareLen+shareTagLen {
+		return "", nil, false
+	}
+	return raw[:dot], share, true
+}
+
+// authenticShare strips and checks the tag of a parsed share.
+func authenticShare(shareKey, share []byte) ([]byte, bool) {
+	if len(shareKey) == 0 {
+		return nil, false
+	}
+	body, tag := share[:shareLen], share[shareLen:]
+	return body, hmac.Equal(tag, shareTag(shareKey, body))
+}
+
+// readShare presents one share towards the release of B. Every response
+// before the last share is the generic failure, so partial progress never
+// shows.
+func (s *Server) readShare(baseRx string, share []byte, access policy.Request) (resp ReadResponse, tripwire *store.Tripwire, ok bool) {
+	defer crypto.Zeroize(share)
+
+	entry, exists := s.store.Get(baseRx)
+	if !exists {
+		return resp, nil, false
+	}
+	tripwire = entry.Tripwire
+
+	s.store.Lock()
+	defer s.store.Unlock()
+
+	if !entry.Policy.Allow(access) || entry.Threshold == 0 || len(entry.Realities) < 2 || entry.Realities[1].Destroyed {
+		return resp, tripwire, false
+	}
+	share, authentic := authenticShare(entry.ShareKey, share)
+	if !authentic {
+		return resp, tripwire, false
+	}
+	shares := s.store.PresentShare(entry, share)
+	if shares == nil {
+		return resp, tripwire, false
+	}
+	key, err := shamir.Combine(shares)
+	for _, sh := range shares {
+		crypto.Zeroize(sh)
+	}
+	if err != nil {
+		return resp, tripwire, false
+	}
+	defer crypto.Zeroize(key)
+
+	reality := entry.Realities[1]
+	ciphertext, err := s.store.Unwrap(entry, reality)
+	if err != nil {
+		return resp, tripwire, false
+	}
+	plaintext, err := crypto.DecryptAESGCM(ciphertext, key, reality.Nonce)
+	if err != nil {
+		return resp, tripwire, false
+	}
+	content, err := normalize.Unpad(plaintext)
+	crypto.Zeroize(plaintext)
+	if err != nil {
+		return resp, tripwire, false
+	}
+
+	resp = ReadResponse{Content: content, Reply: entry.Reply}
+	s.verifySignature(entry, reality, key, content, &resp)
+	s.store.View(reality, reality)
+	entry.Policy.Consume()
+	return resp, tripwire, true
+}
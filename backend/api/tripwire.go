@@ -0,0 +1,186 @@
+package api
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/json"
+	"log"
+	"net/http"
+	"time"
+
+	"zero-system/crypto"
+	"zero-system/normalize"
+	"zero-system/store"
+)
+
+// TripwireRequest plants a honeypot RX slot. It is an operator action and
+// requires the server's operator token; a tripwire that would wipe, lock
+// down or revoke is also held for approvals, see TripwireApproval.
+type TripwireRequest struct {
+	OperatorToken string   `json:"operatorToken"`
+	RxToken       string   `json:"rxToken"`
+	Realities     []string `json:"realities"`  // Plausible content, surface first
+	Action        string   `json:"action"`     // One of the store.Tripwire* actions
+	Namespace     string   `json:"namespace"`  // For wipe-namespace
+	TxToken       string   `json:"txToken"`    // For revoke-tx
+	TTLSeconds    int64    `json:"ttlSeconds"` // Default: Config.TripwireTTL
+	Approval      Approval `json:"approval"`   // When the action's Action* is gated
+}
+
+// LockdownRequest lets an operator start or lift a lockdown.
+type LockdownRequest struct {
+	OperatorToken string   `json:"operatorToken"`
+	Active        bool     `json:"active"`
+	Approval      Approval `json:"approval"` // When ActionLockdown is gated
+}
+
+// Alert is an internal signal for operators. It never reaches the caller
+// that triggered it.
+type Alert struct {
+	Kind   string // e.g. "tripwire"
+	Action string // What was done about it
+	Time   time.Time
+}
+
+func logAlert(a Alert) {
+	log.Printf("🚨 ALERT [%s] action=%s at %s", a.Kind, a.Action, a.Time.Format(time.RFC3339))
+}
+
+// isOperator checks token against the configured operator token.
+// Operator endpoints are disabled while no token is configured.
+func (s *Server) isOperator(token string) bool {
+	if s.operatorToken == "" {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(token), []byte(s.operatorToken)) == 1
+}
+
+func validTripwireAction(action string) bool {
+	switch action {
+	case store.TripwireWipeNamespace, store.TripwireLockdown, store.TripwireRevokeTx, store.TripwireAlert:
+		return true
+	}
+	return false
+}
+
+// TripwireApproval names the approval action planting req needs, and the
+// payload operators sign for it. Alerts, and wipes of an empty namespace,
+// do nothing to gate.
+func TripwireApproval(req TripwireRequest) (action string, payload []byte, gated bool) {
+	var arg string
+	switch req.Action {
+	case store.TripwireWipeNamespace:
+		action, arg, gated = ActionTripwireWipe, req.Namespace, req.Namespace != ""
+	case store.TripwireLockdown:
+		action, arg, gated = ActionTripwireLockdown, string(lockdownPayload(true)), true
+	case store.TripwireRevokeTx:
+		action, arg, gated = ActionTripwireRevoke, req.TxToken, true
+	default:
+		return "", nil, false
+	}
+	slot := sha256.Sum256([]byte(req.RxToken))
+	return action, append(slot[:], arg...), gated
+}
+
+// HandleTripwire plants a canary slot whose content is encrypted and
+// served exactly like a normal message.
+func (s *Server) HandleTripwire(w http.ResponseWriter, r *http.Request) {
+	enableCors(w)
+	if r.Method == "OPTIONS" {
+		return
+	}
+
+	var req TripwireRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.Write([]byte("Note saved")) // Silent degrade
+		return
+	}
+	if !s.isOperator(req.OperatorToken) || !validSlot(req.RxToken) ||
+		!validTripwireAction(req.Action) || len(req.Realities) == 0 || len(req.Realities) > MAX_REALITIES {
+		w.Write([]byte("Note saved")) // Silent failure
+		return
+	}
+	if action, payload, gated := TripwireApproval(req); gated && !s.authorize(action, payload, req.Approval, true) {
+		w.Write([]byte("Note saved")) // Held for approval
+		return
+	}
+
+	padded, err := normalize.Normalize(req.Realities...)
+	if err != nil {
+		w.Write([]byte("Note saved")) // Silent failure
+		return
+	}
+	defer func() {
+		for _, p := range padded {
+			crypto.Zeroize(p)
+		}
+	}()
+
+	realities, _, err := sealRealities(req.RxToken, padded)
+	if err != nil {
+		w.Write([]byte("Note saved")) // Silent failure
+		return
+	}
+
+	ttl := s.tripwireTTL
+	if req.TTLSeconds > 0 {
+		ttl = time.Duration(req.TTLSeconds) * time.Second
+	}
+	entry := &store.SecureEntry{
+		Realities:  realities,
+		ExpiryTime: s.clock.Now().Add(ttl),
+		Tripwire: &store.Tripwire{
+			Action:    req.Action,
+			Namespace: req.Namespace,
+			TxToken:   req.TxToken,
+		},
+	}
+	s.store.Save(req.RxToken, entry)
+
+	w.Write([]byte("Note saved"))
+}
+
+// HandleLockdown starts or lifts a lockdown. While locked down, sends are
+// silently dropped; reads keep working so receivers see nothing unusual.
+// Lifting it also re-arms the brute-force lockdown.
+func (s *Server) HandleLockdown(w http.ResponseWriter, r *http.Request) {
+	enableCors(w)
+	if r.Method == "OPTIONS" {
+		return
+	}
+
+	var req LockdownRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err == nil {
+		allowed := s.isOperator(req.OperatorToken)
+		if s.authorize(ActionLockdown, lockdownPayload(req.Active), req.Approval, allowed) {
+			s.lockdown.Store(req.Active)
+			if !req.Active {
+				s.lockdownFired.Store(false)
+			}
+		}
+	}
+	w.Write([]byte("OK"))
+}
+
+func lockdownPayload(active bool) []byte {
+	if active {
+		return []byte("on")
+	}
+	return []byte("off")
+}
+
+// springTripwire runs a tripwire's action. It is called after the
+// caller's response is written and without the store lock held.
+func (s *Server) springTripwire(t store.Tripwire) {
+	switch t.Action {
+	case store.TripwireWipeNamespace:
+		s.store.WipeNamespace(t.Namespace)
+	case store.TripwireLockdown:
+		s.lockdown.Store(true)
+	case store.TripwireRevokeTx:
+		if t.TxToken != "" {
+			s.revocations.Revoke(t.TxToken)
+		}
+	}
+	s.alert(Alert{Kind: "tripwire", Action: t.Action, Time: s.clock.Now()})
+}
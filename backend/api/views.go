@@ -0,0 +1,38 @@
+package api
+
+import (
+	"errors"
+	"time"
+
+	"zero-system/store"
+)
+
+// MAX_VIEWS bounds how many reads one reality may allow.
+const MAX_VIEWS = 100
+
+var errBadViews = errors.New("api: malformed view policy")
+
+// ViewPolicySpec relaxes burn-on-read for the reality at the same index,
+// e.g. {"views": 3} or {"windowSeconds": 60}. With both, the reality burns
+// on whichever comes first. The zero value burns on the first read.
+type ViewPolicySpec struct {
+	Views         int   `json:"views"`         // Reads allowed, up to MAX_VIEWS
+	WindowSeconds int64 `json:"windowSeconds"` // Readable this long after the first read
+}
+
+// parseViewPolicies checks one spec per reality at most. A view window
+// may not outlive the entry itself.
+func parseViewPolicies(specs []ViewPolicySpec, realities int, ttl time.Duration) ([]store.ViewPolicy, error) {
+	if len(specs) > realities {
+		return nil, errBadViews
+	}
+	policies := make([]store.ViewPolicy, len(specs))
+	for i, spec := range specs {
+		window := time.Duration(spec.WindowSeconds) * time.Second
+		if spec.Views < 0 || spec.Views > MAX_VIEWS || spec.WindowSeconds < 0 || window > ttl {
+			return nil, errBadViews
+		}
+		policies[i] = store.ViewPolicy{Views: spec.Views, Window: window}
+	}
+	return policies, nil
+}
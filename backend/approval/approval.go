@@ -0,0 +1,172 @@
+package approval
+
+import (
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"sync"
+	"time"
+
+	"zero-system/clock"
+)
+
+// Two-person rule for destructive operator actions.
+//
+// An operator approves an action by signing
+//
+//	"zero-approval" 0 action 0 payload 0 issued(8, Unix seconds)
+//
+// with their Ed25519 key. Approvals of the same (action, payload, issued)
+// from k distinct keys of the action's policy, all within its window after
+// issued, release the action exactly once. Pending approvals live in RAM
+// only.
+
+const domain = "zero-approval"
+
+// clockSkew tolerates operators whose clocks run slightly ahead.
+const clockSkew = time.Minute
+
+var (
+	ErrNotGated  = errors.New("approval: action has no policy")
+	ErrUnknown   = errors.New("approval: key is not in the action's policy")
+	ErrSignature = errors.New("approval: bad signature")
+	ErrStale     = errors.New("approval: outside the approval window")
+	ErrDone      = errors.New("approval: proposal already executed")
+)
+
+// Policy says who must approve an action, and how fast.
+type Policy struct {
+	Keys   []ed25519.PublicKey
+	K      int           // Distinct approvals required
+	Window time.Duration // From issue time to the last approval (default 10m)
+}
+
+type proposal struct {
+	signers  map[int]bool // Indexes into Policy.Keys
+	deadline time.Time
+}
+
+// Board collects approvals against per-action policies.
+type Board struct {
+	mu       sync.Mutex
+	clock    clock.Clock
+	policies map[string]Policy
+	pending  map[[sha256.Size]byte]*proposal
+	done     map[[sha256.Size]byte]time.Time // Executed, until their deadline
+}
+
+func NewBoard(policies map[string]Policy, clk clock.Clock) *Board {
+	if clk == nil {
+		clk = clock.Real{}
+	}
+	b := &Board{
+		clock:    clk,
+		policies: make(map[string]Policy, len(policies)),
+		pending:  make(map[[sha256.Size]byte]*proposal),
+		done:     make(map[[sha256.Size]byte]time.Time),
+	}
+	for action, p := range policies {
+		if p.Window <= 0 {
+			p.Window = 10 * time.Minute
+		}
+		if p.K < 1 {
+			p.K = 1
+		}
+		b.policies[action] = p
+	}
+	return b
+}
+
+// Gated reports whether action needs approvals.
+func (b *Board) Gated(action string) bool {
+	_, gated := b.policies[action]
+	return gated
+}
+
+// Approve records one signed approval. It returns true exactly once per
+// proposal: for the approval that completes the quorum.
+func (b *Board) Approve(action string, payload []byte, issued time.Time, key ed25519.PublicKey, sig []byte) (bool, error) {
+	policy, gated := b.policies[action]
+	if !gated {
+		return false, ErrNotGated
+	}
+	signer := -1
+	for i, k := range policy.Keys {
+		if k.Equal(key) {
+			signer = i
+			break
+		}
+	}
+	if signer < 0 {
+		return false, ErrUnknown
+	}
+	msg := Message(action, payload, issued)
+	if !ed25519.Verify(key, msg, sig) {
+		return false, ErrSignature
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	now := b.clock.Now()
+	deadline := issued.Add(policy.Window)
+	if issued.After(now.Add(clockSkew)) || now.After(deadline) {
+		return false, ErrStale
+	}
+	b.sweep(now)
+
+	id := sha256.Sum256(msg)
+	if _, executed := b.done[id]; executed {
+		return false, ErrDone
+	}
+	p, exists := b.pending[id]
+	if !exists {
+		p = &proposal{signers: make(map[int]bool), deadline: deadline}
+		b.pending[id] = p
+	}
+	p.signers[signer] = true
+	if len(p.signers) < policy.K {
+		return false, nil
+	}
+	delete(b.pending, id)
+	b.done[id] = deadline
+	return true, nil
+}
+
+// Wipe forgets every pending approval.
+func (b *Board) Wipe() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.pending = make(map[[sha256.Size]byte]*proposal)
+}
+
+// sweep drops lapsed proposals. Must be called with b.mu held.
+func (b *Board) sweep(now time.Time) {
+	for id, p := range b.pending {
+		if now.After(p.deadline) {
+			delete(b.pending, id)
+		}
+	}
+	for id, deadline := range b.done {
+		if now.After(deadline) {
+			delete(b.done, id)
+		}
+	}
+}
+
+// Message is what an operator signs to approve action with payload.
+func Message(action string, payload []byte, issued time.Time) []byte {
+	msg := make([]byte, 0, len(domain)+len(action)+len(payload)+11)
+	msg = append(msg, domain...)
+	msg = append(msg, 0)
+	msg = append(msg, action...)
+	msg = append(msg, 0)
+	msg = append(msg, payload...)
+	msg = append(msg, 0)
+	return binary.BigEndian.AppendUint64(msg, uint64(issued.Unix()))
+}
+
+// Sign is the operator half of the protocol.
+func Sign(priv ed25519.PrivateKey, action string, payload []byte, issued time.Time) []byte {
+	return ed25519.Sign(priv, Message(action, payload, issued))
+}
@@ -0,0 +1,287 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"math/big"
+	"strings"
+	"sync"
+	"time"
+
+	"zero-system/clock"
+)
+
+// Blind-signed send credentials (RSA-FDH blind signatures, Chaum style).
+//
+// A sender picks a random nonce t, blinds H(t) with a random factor r and
+// has the issuer sign the blinded value. Unblinding yields a plain RSA
+// signature on H(t) that the issuer has never seen. Spending the
+// credential reveals t and the signature, which the issuer cannot link to
+// the issuance that produced them.
+//
+//	credential = "BT-" || base64url(t(32) || signature)
+const (
+	BlindTokenPrefix = "BT-"
+	blindNonceLen    = 32
+	fdhDomain        = "zero-system blind token v1"
+)
+
+var ErrBadBlindToken = errors.New("auth: malformed blind token")
+
+// IsBlindToken reports whether tx is a blind-signed credential rather than
+// a plain TX token.
+func IsBlindToken(tx string) bool {
+	return strings.HasPrefix(tx, BlindTokenPrefix)
+}
+
+// BlindIssuer signs blinded credentials and spends unblinded ones.
+//
+// The signing key rotates every lifetime. A credential can be spent while
+// the key it was issued under is current or has just been retired, so for
+// at least one lifetime after issuance; spent credentials are remembered
+// by digest, in RAM only, for as long as their key is accepted.
+type BlindIssuer struct {
+	bits     int
+	lifetime time.Duration
+	clock    clock.Clock
+
+	mu       sync.Mutex
+	current  *blindKey
+	previous *blindKey // Retired at the last rotation, still spendable
+}
+
+type blindKey struct {
+	key    *rsa.PrivateKey
+	dp, dq *big.Int // CRT exponents d mod (p-1), d mod (q-1)
+	qInv   *big.Int // q^-1 mod p
+	born   time.Time
+	spent  map[[sha256.Size]byte]struct{}
+}
+
+func NewBlindIssuer(bits int, lifetime time.Duration, clk clock.Clock) (*BlindIssuer, error) {
+	if clk == nil {
+		clk = clock.Real{}
+	}
+	i := &BlindIssuer{bits: bits, lifetime: lifetime, clock: clk}
+	key, err := newBlindKey(bits, clk.Now())
+	if err != nil {
+		return nil, err
+	}
+	i.current = key
+	return i, nil
+}
+
+func newBlindKey(bits int, born time.Time) (*blindKey, error) {
+	key, err := rsa.GenerateKey(rand.Reader, bits)
+	if err != nil {
+		return nil, err
+	}
+	p, q := key.Primes[0], key.Primes[1]
+	one := big.NewInt(1)
+	return &blindKey{
+		key:   key,
+		dp:    new(big.Int).Mod(key.D, new(big.Int).Sub(p, one)),
+		dq:    new(big.Int).Mod(key.D, new(big.Int).Sub(q, one)),
+		qInv:  new(big.Int).ModInverse(q, p),
+		born:  born,
+		spent: make(map[[sha256.Size]byte]struct{}),
+	}, nil
+}
+
+// rotate retires the current key once its lifetime is over, forgetting
+// the key retired before it and every credential spent under that one.
+// Must be called with i.mu held.
+func (i *BlindIssuer) rotate() error {
+	now := i.clock.Now()
+	if now.Before(i.current.born.Add(i.lifetime)) {
+		return nil
+	}
+	key, err := newBlindKey(i.bits, now)
+	if err != nil {
+		return err
+	}
+	i.previous = i.current
+	if !now.Before(i.current.born.Add(2 * i.lifetime)) {
+		i.previous = nil // Idle for a whole lifetime: nothing is still spendable
+	}
+	i.current = key
+	return nil
+}
+
+// PublicKey is what senders blind against and verifiers check with.
+func (i *BlindIssuer) PublicKey() *rsa.PublicKey {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.rotate()
+	return &i.current.key.PublicKey
+}
+
+// Sign signs a blinded value under the current key. The issuer learns
+// nothing about the credential it will become. A sender that blinded
+// against a key retired since then gets a signature that does not
+// finalize, and starts again with the new key.
+func (i *BlindIssuer) Sign(blinded []byte) ([]byte, error) {
+	i.mu.Lock()
+	if err := i.rotate(); err != nil {
+		i.mu.Unlock()
+		return nil, err
+	}
+	k := i.current
+	i.mu.Unlock()
+
+	m := new(big.Int).SetBytes(blinded)
+	if m.Sign() == 0 || m.Cmp(k.key.N) >= 0 {
+		return nil, ErrBadBlindToken
+	}
+	s, err := k.sign(m)
+	if err != nil {
+		return nil, err
+	}
+	return s.FillBytes(make([]byte, k.key.Size())), nil
+}
+
+// sign computes m^d mod N. The private exponentiation never runs on m
+// itself: m is first multiplied by r^e for a fresh random r, so its
+// timing is independent of anything the caller chose, and the result is
+// checked against the public key before it leaves, so a faulty CRT
+// computation cannot leak a factor of N.
+func (k *blindKey) sign(m *big.Int) (*big.Int, error) {
+	pub := &k.key.PublicKey
+	var r, rInv *big.Int
+	for {
+		var err error
+		r, err = rand.Int(rand.Reader, pub.N)
+		if err != nil {
+			return nil, err
+		}
+		if rInv = new(big.Int).ModInverse(r, pub.N); r.Sign() > 0 && rInv != nil {
+			break
+		}
+	}
+	e := big.NewInt(int64(pub.E))
+	c := new(big.Int).Exp(r, e, pub.N)
+	c.Mul(c, m).Mod(c, pub.N)
+
+	// CRT: s = m2 + q * (qInv * (m1 - m2) mod p)
+	p, q := k.key.Primes[0], k.key.Primes[1]
+	m1 := new(big.Int).Exp(c, k.dp, p)
+	m2 := new(big.Int).Exp(c, k.dq, q)
+	h := m1.Sub(m1, m2)
+	h.Mul(h, k.qInv).Mod(h, p)
+	s := h.Mul(h, q).Add(h, m2)
+
+	s.Mul(s, rInv).Mod(s, pub.N)
+	if new(big.Int).Exp(s, e, pub.N).Cmp(m) != 0 {
+		return nil, ErrBadBlindToken
+	}
+	return s, nil
+}
+
+// Spend verifies a credential against the current or the just retired key
+// and marks it used. It returns false for forged, malformed, expired or
+// already spent credentials.
+func (i *BlindIssuer) Spend(token string) bool {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.rotate()
+	for _, k := range []*blindKey{i.current, i.previous} {
+		if k == nil {
+			continue
+		}
+		nonce, sig, err := parseBlindToken(&k.key.PublicKey, token)
+		if err != nil || !verifyFDH(&k.key.PublicKey, nonce, sig) {
+			continue
+		}
+		digest := sha256.Sum256(nonce)
+		if _, used := k.spent[digest]; used {
+			return false
+		}
+		k.spent[digest] = struct{}{}
+		return true
+	}
+	return false
+}
+
+// BlindRequest is the sender's half of one issuance.
+type BlindRequest struct {
+	nonce []byte
+	r     *big.Int // Blinding factor, never sent
+}
+
+// Blind starts an issuance against pub. The returned value goes to the
+// issuer; the request is kept to finalize its answer.
+func Blind(pub *rsa.PublicKey) (*BlindRequest, []byte, error) {
+	nonce := make([]byte, blindNonceLen)
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, nil, err
+	}
+
+	var r *big.Int
+	for {
+		var err error
+		r, err = rand.Int(rand.Reader, pub.N)
+		if err != nil {
+			return nil, nil, err
+		}
+		if r.Sign() > 0 && new(big.Int).GCD(nil, nil, r, pub.N).Cmp(big.NewInt(1)) == 0 {
+			break
+		}
+	}
+
+	// m' = H(t) * r^e mod N
+	blinded := new(big.Int).Exp(r, big.NewInt(int64(pub.E)), pub.N)
+	blinded.Mul(blinded, fdh(pub, nonce)).Mod(blinded, pub.N)
+	return &BlindRequest{nonce: nonce, r: r}, blinded.FillBytes(make([]byte, pub.Size())), nil
+}
+
+// Finalize unblinds the issuer's signature into a spendable credential.
+func (b *BlindRequest) Finalize(pub *rsa.PublicKey, blindSig []byte) (string, error) {
+	// s = s' * r^-1 mod N
+	s := new(big.Int).SetBytes(blindSig)
+	s.Mul(s, new(big.Int).ModInverse(b.r, pub.N)).Mod(s, pub.N)
+	sig := s.FillBytes(make([]byte, pub.Size()))
+	if !verifyFDH(pub, b.nonce, sig) {
+		return "", ErrBadBlindToken
+	}
+	return BlindTokenPrefix + base64.RawURLEncoding.EncodeToString(append(b.nonce, sig...)), nil
+}
+
+func parseBlindToken(pub *rsa.PublicKey, token string) (nonce, sig []byte, err error) {
+	if !IsBlindToken(token) {
+		return nil, nil, ErrBadBlindToken
+	}
+	raw, err := base64.RawURLEncoding.DecodeString(token[len(BlindTokenPrefix):])
+	if err != nil || len(raw) != blindNonceLen+pub.Size() {
+		return nil, nil, ErrBadBlindToken
+	}
+	return raw[:blindNonceLen], raw[blindNonceLen:], nil
+}
+
+func verifyFDH(pub *rsa.PublicKey, nonce, sig []byte) bool {
+	s := new(big.Int).SetBytes(sig)
+	if s.Cmp(pub.N) >= 0 {
+		return false
+	}
+	m := new(big.Int).Exp(s, big.NewInt(int64(pub.E)), pub.N)
+	return m.Cmp(fdh(pub, nonce)) == 0
+}
+
+// fdh is a full-domain hash of nonce onto [0, N): SHA-256 in counter mode,
+// stretched to the modulus size and reduced.
+func fdh(pub *rsa.PublicKey, nonce []byte) *big.Int {
+	out := make([]byte, 0, pub.Size()+sha256.Size)
+	var counter [4]byte
+	for i := uint32(0); len(out) < pub.Size(); i++ {
+		binary.BigEndian.PutUint32(counter[:], i)
+		h := sha256.New()
+		h.Write([]byte(fdhDomain))
+		h.Write(counter[:])
+		h.Write(nonce)
+		out = h.Sum(out)
+	}
+	return new(big.Int).Mod(new(big.Int).SetBytes(out[:pub.Size()]), pub.N)
+}
@@ -0,0 +1,32 @@
+package auth
+
+import (
+	"crypto/sha256"
+	"sync"
+)
+
+// RevocationList holds revoked TX tokens in RAM.
+// Only SHA-256 digests are kept, so the list never holds a usable token.
+type RevocationList struct {
+	mu      sync.RWMutex
+	revoked map[[sha256.Size]byte]struct{}
+}
+
+func NewRevocationList() *RevocationList {
+	return &RevocationList{revoked: make(map[[sha256.Size]byte]struct{})}
+}
+
+// Revoke blocks tx from sending again.
+func (l *RevocationList) Revoke(tx string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.revoked[sha256.Sum256([]byte(tx))] = struct{}{}
+}
+
+// IsRevoked reports whether tx has been revoked.
+func (l *RevocationList) IsRevoked(tx string) bool {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	_, revoked := l.revoked[sha256.Sum256([]byte(tx))]
+	return revoked
+}
@@ -9,12 +9,23 @@ import (
 	"io"
 	"math"
 	"net/http"
+	"net/http/httptest"
 	"os"
 	"sync"
 	"time"
+
+	"zero-system/api"
+	"zero-system/clock"
+	"zero-system/guard"
+	"zero-system/ratelimit"
 )
 
-const BASE_URL = "http://localhost:8080"
+// BASE_URL is the in-process server main starts. Its limiters run on a
+// fake clock, so they refill between layers and timing samples without
+// waiting on the wall clock; everything else, timing included, runs in
+// real time.
+var BASE_URL string
+
 const TARGET_RESPONSE_SIZE = 4096
 
 // --- Structs ---
@@ -167,10 +178,13 @@ func TestLayer5_Concurrency() bool {
 	return true
 }
 
-func TestLayer6_Timing() bool {
+// TestLayer6_Timing refills the limiters on fake before every sample, so
+// that no sample is answered by the rate limiter instead of a lookup.
+func TestLayer6_Timing(fake *clock.Fake) bool {
 	fmt.Println("🔹 TEST: Timing Uniformity (Layer 6)")
 
 	// Warmup
+	fake.Advance(time.Second)
 	readNote("WARMUP")
 
 	var validTimes []time.Duration
@@ -178,6 +192,7 @@ func TestLayer6_Timing() bool {
 
 	// Collect Invalid Samples
 	for i := 0; i < 20; i++ {
+		fake.Advance(time.Second) // One read token
 		start := time.Now()
 		readNote("INVALID-RX")
 		invalidTimes = append(invalidTimes, time.Since(start))
@@ -187,6 +202,7 @@ func TestLayer6_Timing() bool {
 	rxBase := "CI-TIME-"
 	for i := 0; i < 20; i++ {
 		rx := fmt.Sprintf("%s%d", rxBase, i)
+		fake.Advance(15 * time.Second) // One send token and one read token
 		sendNote("TX-Valid", rx, "A", "B")
 		start := time.Now()
 		readNote(rx)
@@ -215,26 +231,40 @@ func main() {
 	fmt.Println("🔒 ZERO SECURITY CI SUITE 🔒")
 	fmt.Println("============================")
 
+	// The guard only locks down: twenty invalid reads in a row would
+	// otherwise tarpit the timing layer, which then measures the delay
+	// instead of the lookup.
+	fake := clock.NewFake(time.Now())
+	server := api.NewServer(api.Config{
+		SendLimiter: ratelimit.NewLimiter(0.083, 2, fake), // The standalone binary's limits
+		ReadLimiter: ratelimit.NewLimiter(1.0, 10, fake),
+		Guard: guard.Config{
+			Client: guard.Thresholds{Lockdown: 1000},
+			Global: guard.Thresholds{Lockdown: 1000},
+		},
+	})
+	ts := httptest.NewServer(server.Handler())
+	BASE_URL = ts.URL
+
 	passed := true
 
 	if !TestLayer3and4_Logic() {
 		passed = false
 	}
 
-	// Cool-down for Rate Limiter (Burst 10 consumed by Timing Test)
-	fmt.Println("  ... Cooling down for 15s (Token Refill) ...")
-	time.Sleep(15 * time.Second)
-
-	if !TestLayer6_Timing() {
+	if !TestLayer6_Timing(fake) {
 		passed = false
 	} // Run timing before concurrency to avoid noisy network
 
-	fmt.Println("  ... Cooling down for 15s (Token Refill) ...")
-	time.Sleep(15 * time.Second)
+	// Refill for the race: one send, then the full burst of 10 reads at once
+	fmt.Println("  ... Advancing the clock 15s (Token Refill) ...")
+	fake.Advance(15 * time.Second)
 
 	if !TestLayer5_Concurrency() {
 		passed = false
 	}
+	ts.Close()
+	server.Close()
 
 	if passed {
 		fmt.Println("\n✅ ALL CI CHECKS PASSED")
@@ -0,0 +1,153 @@
+package clock
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// Clock is the time source used by the store, the limiters and the API.
+// It is injected so that TTL, dead man and refill behaviour can be driven
+// by a Fake clock instead of waiting on the wall clock.
+type Clock interface {
+	Now() time.Time
+	// AfterFunc calls f in its own goroutine (Real) or inline (Fake)
+	// once d has elapsed.
+	AfterFunc(d time.Duration, f func()) Timer
+}
+
+// Timer is a pending AfterFunc call. *time.Timer satisfies it.
+type Timer interface {
+	Stop() bool
+	Reset(d time.Duration) bool
+}
+
+// Real is the wall clock.
+type Real struct{}
+
+func (Real) Now() time.Time {
+	return time.Now()
+}
+
+func (Real) AfterFunc(d time.Duration, f func()) Timer {
+	return time.AfterFunc(d, f)
+}
+
+// Since is time.Since measured against c.
+func Since(c Clock, t time.Time) time.Duration {
+	return c.Now().Sub(t)
+}
+
+// Sleep blocks until d has elapsed on c. On a Fake clock it returns once
+// another goroutine advances the clock far enough.
+func Sleep(c Clock, d time.Duration) {
+	if d <= 0 {
+		return
+	}
+	done := make(chan struct{})
+	c.AfterFunc(d, func() { close(done) })
+	<-done
+}
+
+// Fake is a manual clock. Time only moves when Advance or Set is called,
+// and due timers fire synchronously inside that call, in deadline order.
+type Fake struct {
+	mu     sync.Mutex
+	now    time.Time
+	timers []*fakeTimer
+}
+
+// NewFake returns a Fake clock frozen at start.
+func NewFake(start time.Time) *Fake {
+	return &Fake{now: start}
+}
+
+func (f *Fake) Now() time.Time {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.now
+}
+
+func (f *Fake) AfterFunc(d time.Duration, fn func()) Timer {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	t := &fakeTimer{clock: f, fn: fn}
+	f.schedule(t, d)
+	return t
+}
+
+// Advance moves the clock forward by d, firing every timer that falls due.
+func (f *Fake) Advance(d time.Duration) {
+	f.Set(f.Now().Add(d))
+}
+
+// Set moves the clock to t, firing every timer due at or before t.
+// Callbacks run without the clock lock held, so they may schedule new
+// timers; those fire too if they fall due before t.
+func (f *Fake) Set(t time.Time) {
+	for {
+		f.mu.Lock()
+		if len(f.timers) == 0 || f.timers[0].deadline.After(t) {
+			if t.After(f.now) {
+				f.now = t
+			}
+			f.mu.Unlock()
+			return
+		}
+		next := f.timers[0]
+		f.timers = f.timers[1:]
+		next.active = false
+		if next.deadline.After(f.now) {
+			f.now = next.deadline
+		}
+		f.mu.Unlock()
+
+		next.fn()
+	}
+}
+
+// schedule must be called with f.mu held.
+func (f *Fake) schedule(t *fakeTimer, d time.Duration) {
+	t.deadline = f.now.Add(d)
+	t.active = true
+	f.timers = append(f.timers, t)
+	sort.SliceStable(f.timers, func(i, j int) bool {
+		return f.timers[i].deadline.Before(f.timers[j].deadline)
+	})
+}
+
+// unschedule must be called with f.mu held.
+func (f *Fake) unschedule(t *fakeTimer) bool {
+	if !t.active {
+		return false
+	}
+	for i, other := range f.timers {
+		if other == t {
+			f.timers = append(f.timers[:i], f.timers[i+1:]...)
+			break
+		}
+	}
+	t.active = false
+	return true
+}
+
+type fakeTimer struct {
+	clock    *Fake
+	fn       func()
+	deadline time.Time
+	active   bool
+}
+
+func (t *fakeTimer) Stop() bool {
+	t.clock.mu.Lock()
+	defer t.clock.mu.Unlock()
+	return t.clock.unschedule(t)
+}
+
+func (t *fakeTimer) Reset(d time.Duration) bool {
+	t.clock.mu.Lock()
+	defer t.clock.mu.Unlock()
+	wasActive := t.clock.unschedule(t)
+	t.clock.schedule(t, d)
+	return wasActive
+}
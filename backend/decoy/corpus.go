@@ -0,0 +1,51 @@
+package decoy
+
+// DefaultCorpus is the built-in corpus: everyday notes-app content.
+func DefaultCorpus() *Corpus {
+	return &Corpus{
+		Headings: []string{
+			"Groceries", "To do", "Weekend", "Packing list", "Errands",
+			"Shopping {day}", "Ideas", "Books to read", "Gift ideas", "Meal plan",
+		},
+		Items: []string{
+			"{food}", "{food}", "{food} x{count}", "2 {food}", "more {food}",
+			"call {name} back", "pay {bill} bill", "pick up {thing} from {place}",
+			"return {thing}", "book {appointment} for {day}", "{chore}", "{chore} before {day}",
+			"ask {name} about {thing}", "charger for {thing}", "renew {document}",
+			"{name}'s birthday on {day}", "water the plants", "check {place} opening hours",
+			"buy {thing}", "email {name} re {topic}", "{time} {appointment}",
+		},
+		Sentences: []string{
+			"Need to remember to {chore} before {day}.",
+			"{name} said the {place} closes early on {day}.",
+			"Don't forget {food} and {food} on the way home.",
+			"Meeting with {name} moved to {time} on {day}.",
+			"Try the new recipe with {food} this weekend.",
+			"{appointment} on {day} at {time}, bring the {document}.",
+			"The {thing} still needs fixing, maybe ask {name}.",
+			"Look into a cheaper plan for the {bill} bill.",
+			"{name} recommended a place near the {place}.",
+			"Remember to send {name} the notes about {topic}.",
+			"Sort out the {thing} before the trip.",
+			"Might skip the {place} this week, too busy.",
+		},
+		Words: map[string][]string{
+			"food": {
+				"milk", "eggs", "bread", "butter", "coffee", "tea", "apples", "bananas",
+				"rice", "pasta", "tomatoes", "onions", "cheese", "yogurt", "chicken",
+				"oat milk", "spinach", "lemons", "olive oil", "cereal",
+			},
+			"name":        {"Anna", "Tom", "Sam", "Mia", "Leo", "Jo", "mum", "dad", "Alex", "Kim"},
+			"day":         {"Monday", "Tuesday", "Wednesday", "Thursday", "Friday", "Saturday", "Sunday", "the weekend"},
+			"time":        {"9:00", "10:30", "12:15", "14:00", "15:30", "17:45", "18:00"},
+			"count":       {"2", "3", "4", "6"},
+			"bill":        {"phone", "internet", "electricity", "water", "gym"},
+			"thing":       {"bike", "laptop", "jacket", "keys", "umbrella", "parcel", "lamp", "printer"},
+			"place":       {"pharmacy", "post office", "library", "gym", "dry cleaner", "market", "bakery"},
+			"appointment": {"dentist", "haircut", "doctor", "car service", "vet"},
+			"chore":       {"do the laundry", "clean the fridge", "take out recycling", "vacuum", "change the sheets"},
+			"document":    {"passport", "insurance card", "library card", "receipt"},
+			"topic":       {"the trip", "the budget", "the party", "the flat", "the project"},
+		},
+	}
+}
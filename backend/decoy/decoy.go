@@ -0,0 +1,202 @@
+package decoy
+
+import (
+	crand "crypto/rand"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// Decoy generation for the surface reality.
+//
+// A Generator writes plausible notes-app content from a Corpus of
+// headings, list items, sentences and word lists, entirely locally. It
+// imitates the shape of the text it covers for: a bulleted list stays a
+// bulleted list, loose lines stay loose lines, prose stays prose, and the
+// result is about as long, but never noticeably longer. Templates may use
+// {placeholders} filled from the corpus's word lists.
+
+var (
+	ErrEmptyCorpus = errors.New("decoy: corpus needs items and sentences")
+	placeholder    = regexp.MustCompile(`\{([a-z_]+)\}`)
+)
+
+// Corpus is what decoys are made of. Operators can supply their own as
+// JSON with the same field names.
+type Corpus struct {
+	Headings  []string            `json:"headings"`  // First lines of list notes
+	Items     []string            `json:"items"`     // List entries and loose lines
+	Sentences []string            `json:"sentences"` // Prose
+	Words     map[string][]string `json:"words"`     // Fillers for {placeholders}
+}
+
+// Validate checks that the corpus can produce every style and that every
+// placeholder has words to fill it.
+func (c *Corpus) Validate() error {
+	if len(c.Items) == 0 || len(c.Sentences) == 0 {
+		return ErrEmptyCorpus
+	}
+	for _, lists := range [][]string{c.Headings, c.Items, c.Sentences} {
+		for _, template := range lists {
+			for _, m := range placeholder.FindAllStringSubmatch(template, -1) {
+				if len(c.Words[m[1]]) == 0 {
+					return fmt.Errorf("decoy: no words for {%s}", m[1])
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// LoadCorpus reads and validates a JSON corpus.
+func LoadCorpus(path string) (*Corpus, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var c Corpus
+	if err := json.Unmarshal(raw, &c); err != nil {
+		return nil, err
+	}
+	if err := c.Validate(); err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+// Kind is the layout of a note.
+type Kind int
+
+const (
+	Prose Kind = iota // Sentences, in paragraphs
+	Lines             // One short entry per line
+	List              // A heading, then bulleted entries
+)
+
+// Style is the shape a decoy imitates.
+type Style struct {
+	Kind   Kind
+	Bullet string // List only, e.g. "- " or "• "
+}
+
+var bullets = []string{"- ", "* ", "• ", "– "}
+
+// StyleOf guesses the style of text.
+func StyleOf(text string) Style {
+	lines := strings.Split(strings.TrimSpace(text), "\n")
+	if len(lines) < 2 {
+		return Style{Kind: Prose}
+	}
+	for _, b := range bullets {
+		n := 0
+		for _, line := range lines {
+			if strings.HasPrefix(strings.TrimSpace(line), strings.TrimSpace(b)) {
+				n++
+			}
+		}
+		if n*2 >= len(lines) {
+			return Style{Kind: List, Bullet: b}
+		}
+	}
+	return Style{Kind: Lines}
+}
+
+// Generator writes decoys. It is safe for concurrent use.
+type Generator struct {
+	mu     sync.Mutex
+	corpus *Corpus
+	rng    *rand.Rand
+}
+
+// New returns a generator over corpus (DefaultCorpus if nil), seeded from
+// the system's secure random source.
+func New(corpus *Corpus) *Generator {
+	var seed [32]byte
+	crand.Read(seed[:])
+	return newGenerator(corpus, seed)
+}
+
+// NewSeeded returns a generator whose output depends only on corpus and
+// seed, for tests.
+func NewSeeded(corpus *Corpus, seed uint64) *Generator {
+	var s [32]byte
+	binary.LittleEndian.PutUint64(s[:], seed)
+	return newGenerator(corpus, s)
+}
+
+func newGenerator(corpus *Corpus, seed [32]byte) *Generator {
+	if corpus == nil {
+		corpus = DefaultCorpus()
+	}
+	return &Generator{corpus: corpus, rng: rand.New(rand.NewChaCha8(seed))}
+}
+
+// For writes a decoy in the style of text, at most about as long.
+func (g *Generator) For(text string) string {
+	return g.Generate(utf8.RuneCountInString(text), StyleOf(text))
+}
+
+// Generate writes a decoy of style with close to length characters. It
+// stops before the entry that would overshoot length, but always writes
+// at least one entry.
+func (g *Generator) Generate(length int, style Style) string {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	var b strings.Builder
+	count := 0
+	add := func(sep, part string) bool {
+		if count > 0 && utf8.RuneCountInString(b.String())+utf8.RuneCountInString(sep+part) > length {
+			return false
+		}
+		if count > 0 {
+			b.WriteString(sep)
+		}
+		b.WriteString(part)
+		count++
+		return true
+	}
+
+	switch style.Kind {
+	case List:
+		if len(g.corpus.Headings) > 0 {
+			add("", g.fill(g.pick(g.corpus.Headings)))
+		}
+		for add("\n", style.Bullet+g.fill(g.pick(g.corpus.Items))) {
+		}
+	case Lines:
+		for add("\n", g.fill(g.pick(g.corpus.Items))) {
+		}
+	default:
+		sep := " "
+		for add(sep, g.fill(g.pick(g.corpus.Sentences))) {
+			sep = " "
+			if g.rng.IntN(4) == 0 {
+				sep = "\n\n"
+			}
+		}
+	}
+	return b.String()
+}
+
+func (g *Generator) pick(list []string) string {
+	return list[g.rng.IntN(len(list))]
+}
+
+// fill replaces every {placeholder} with a random word of its list.
+func (g *Generator) fill(template string) string {
+	return placeholder.ReplaceAllStringFunc(template, func(m string) string {
+		words := g.corpus.Words[m[1:len(m)-1]]
+		if len(words) == 0 {
+			return ""
+		}
+		return g.pick(words)
+	})
+}
@@ -0,0 +1,193 @@
+package guard
+
+import (
+	"math"
+	"sync"
+	"time"
+
+	"zero-system/clock"
+)
+
+// Stage is how hard a caller is being pushed back.
+// Stages only ever add friction; responses stay camouflaged at every stage.
+type Stage int
+
+const (
+	StageNormal    Stage = iota
+	StageTarpit          // Responses are delayed
+	StageChallenge       // Reads require a proof-of-work solution
+	StageLockdown        // The operator-defined lockdown is in effect
+)
+
+func (s Stage) String() string {
+	switch s {
+	case StageTarpit:
+		return "tarpit"
+	case StageChallenge:
+		return "challenge"
+	case StageLockdown:
+		return "lockdown"
+	}
+	return "normal"
+}
+
+// Thresholds are decayed failure scores at which each stage starts.
+type Thresholds struct {
+	Tarpit    float64
+	Challenge float64
+	Lockdown  float64
+}
+
+func (t Thresholds) stage(score float64) Stage {
+	switch {
+	case t.Lockdown > 0 && score >= t.Lockdown:
+		return StageLockdown
+	case t.Challenge > 0 && score >= t.Challenge:
+		return StageChallenge
+	case t.Tarpit > 0 && score >= t.Tarpit:
+		return StageTarpit
+	}
+	return StageNormal
+}
+
+// Config tunes a Detector. Zero values fall back to the defaults below.
+type Config struct {
+	HalfLife time.Duration // Time for a failure score to halve (default 10m)
+	Client   Thresholds    // Per client key (default 5 / 10 / 50)
+	Global   Thresholds    // Across all clients (default 50 / 100 / 500)
+}
+
+// counter is an exponentially decaying failure score.
+type counter struct {
+	score   float64
+	updated time.Time
+}
+
+func (c *counter) decay(now time.Time, halfLife time.Duration) float64 {
+	if elapsed := now.Sub(c.updated); elapsed > 0 {
+		c.score *= math.Exp2(-float64(elapsed) / float64(halfLife))
+		c.updated = now
+	}
+	return c.score
+}
+
+// Detector tracks failed reads per client key and globally.
+type Detector struct {
+	mu      sync.Mutex
+	cfg     Config
+	clients map[string]*counter
+	global  counter
+
+	clock        clock.Clock
+	cleanupTimer clock.Timer
+	closed       bool
+}
+
+func NewDetector(cfg Config, clk clock.Clock) *Detector {
+	if clk == nil {
+		clk = clock.Real{}
+	}
+	if cfg.HalfLife <= 0 {
+		cfg.HalfLife = 10 * time.Minute
+	}
+	if cfg.Client == (Thresholds{}) {
+		cfg.Client = Thresholds{Tarpit: 5, Challenge: 10, Lockdown: 50}
+	}
+	if cfg.Global == (Thresholds{}) {
+		cfg.Global = Thresholds{Tarpit: 50, Challenge: 100, Lockdown: 500}
+	}
+
+	d := &Detector{
+		cfg:     cfg,
+		clients: make(map[string]*counter),
+		global:  counter{updated: clk.Now()},
+		clock:   clk,
+	}
+	d.mu.Lock()
+	d.cleanupTimer = clk.AfterFunc(cfg.HalfLife, d.cleanup)
+	d.mu.Unlock()
+	return d
+}
+
+// Fail records one failed read by client and returns the resulting stage.
+func (d *Detector) Fail(client string) Stage {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	now := d.clock.Now()
+
+	c, exists := d.clients[client]
+	if !exists {
+		c = &counter{updated: now}
+		d.clients[client] = c
+	}
+	c.decay(now, d.cfg.HalfLife)
+	c.score++
+	d.global.decay(now, d.cfg.HalfLife)
+	d.global.score++
+
+	return d.stageLocked(client, now)
+}
+
+// Stage returns the stage client is in: the stricter of its own stage and
+// the global one.
+func (d *Detector) Stage(client string) Stage {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.stageLocked(client, d.clock.Now())
+}
+
+// ClientStage returns the stage client has reached on its own failures,
+// ignoring the global one.
+func (d *Detector) ClientStage(client string) Stage {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	c, exists := d.clients[client]
+	if !exists {
+		return StageNormal
+	}
+	return d.cfg.Client.stage(c.decay(d.clock.Now(), d.cfg.HalfLife))
+}
+
+// GlobalStage returns the stage of the server as a whole.
+func (d *Detector) GlobalStage() Stage {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.cfg.Global.stage(d.global.decay(d.clock.Now(), d.cfg.HalfLife))
+}
+
+func (d *Detector) stageLocked(client string, now time.Time) Stage {
+	stage := d.cfg.Global.stage(d.global.decay(now, d.cfg.HalfLife))
+	if c, exists := d.clients[client]; exists {
+		if own := d.cfg.Client.stage(c.decay(now, d.cfg.HalfLife)); own > stage {
+			stage = own
+		}
+	}
+	return stage
+}
+
+// cleanup forgets clients whose score has decayed to nothing.
+func (d *Detector) cleanup() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.closed {
+		return
+	}
+	now := d.clock.Now()
+	for client, c := range d.clients {
+		if c.decay(now, d.cfg.HalfLife) < 0.01 {
+			delete(d.clients, client)
+		}
+	}
+	d.cleanupTimer.Reset(d.cfg.HalfLife)
+}
+
+// Close disarms the cleanup timer.
+func (d *Detector) Close() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.closed {
+		return
+	}
+	d.closed = true
+	d.cleanupTimer.Stop()
+}
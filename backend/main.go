@@ -1,10 +1,19 @@
 package main
 
 import (
+	"crypto/ed25519"
+	"encoding/base64"
 	"fmt"
 	"net/http"
+	"net/netip"
+	"os"
+	"strconv"
+	"strings"
 	"zero-system/api"
+	"zero-system/approval"
+	"zero-system/clock"
 	"zero-system/crypto"
+	"zero-system/decoy"
 	"zero-system/ratelimit"
 	"zero-system/store"
 )
@@ -15,28 +24,102 @@ func main() {
 	// 0. Initialize Secure Memory
 	crypto.InitSecureMemory()
 
+	wallClock := clock.Real{}
+
 	// 1. Initialize Memory Store
-	store.InitStore()
+	memStore := store.NewMemoryStore(wallClock)
+	defer memStore.Close()
 	fmt.Println("✓ Memory Store Initialized")
 
 	// 2. Initialize Rate Limiters
 	// Send: 5 per minute (Strict) -> 0.083 tokens/sec, Burst 2
-	sendLimiter := ratelimit.NewLimiter(0.083, 2)
+	sendLimiter := ratelimit.NewLimiter(0.083, 2, wallClock)
+	defer sendLimiter.Close()
 
 	// Read: 60 per minute (Normal + Noise) -> 1 token/sec, Burst 10
-	readLimiter := ratelimit.NewLimiter(1.0, 10)
+	readLimiter := ratelimit.NewLimiter(1.0, 10, wallClock)
+	defer readLimiter.Close()
 
 	fmt.Println("✓ Rate Limiting Active (DDoS Protection)")
 
-	// 3. Register Routes with Middleware
-	http.HandleFunc("/api/send", sendLimiter.Middleware(api.HandleSend))
-	http.HandleFunc("/api/read", readLimiter.Middleware(api.HandleRead))
-	http.HandleFunc("/api/panic", api.HandlePanic) // No rate limit? Or shared?
-	http.HandleFunc("/api/heartbeat", api.HandleHeartbeat)
+	// 3. Assemble Server (Routes + Middleware)
+	server := api.NewServer(api.Config{
+		Store:       memStore,
+		SendLimiter: sendLimiter,
+		ReadLimiter: readLimiter,
+		Clock:       wallClock,
+
+		// Operator endpoints (tripwires, lockdown) stay disabled without a token
+		OperatorToken: os.Getenv("ZERO_OPERATOR_TOKEN"),
+		Admission:     os.Getenv("ZERO_ADMISSION"), // "ratelimit" (default), "pow" or "both"
+		Approvals:     approvalPolicies(),
+		Decoys:        decoyGenerator(),
+
+		TrustedProxies: trustedProxies(),
+	})
+	defer server.Close()
 
 	// 4. Start Server
 	fmt.Println("✓ Listening on :8080")
-	if err := http.ListenAndServe(":8080", nil); err != nil {
+	if err := http.ListenAndServe(":8080", server.Handler()); err != nil {
 		panic(err)
 	}
 }
+
+// approvalPolicies puts panic, dead man changes, revocation and lockdown
+// under a two-person rule when ZERO_APPROVAL_KEYS lists operator keys
+// (comma-separated, base64url Ed25519). ZERO_APPROVAL_K sets the quorum
+// (default 2).
+func approvalPolicies() map[string]approval.Policy {
+	var keys []ed25519.PublicKey
+	for _, encoded := range strings.Split(os.Getenv("ZERO_APPROVAL_KEYS"), ",") {
+		key, err := base64.RawURLEncoding.DecodeString(strings.TrimSpace(encoded))
+		if err == nil && len(key) == ed25519.PublicKeySize {
+			keys = append(keys, key)
+		}
+	}
+	if len(keys) == 0 {
+		return nil
+	}
+	k, err := strconv.Atoi(os.Getenv("ZERO_APPROVAL_K"))
+	if err != nil || k < 1 {
+		k = 2
+	}
+
+	policy := approval.Policy{Keys: keys, K: k}
+	fmt.Printf("✓ Two-Person Rule Active (%d of %d operator keys)\n", k, len(keys))
+	return map[string]approval.Policy{
+		api.ActionPanic:    policy,
+		api.ActionDeadMan:  policy,
+		api.ActionRevoke:   policy,
+		api.ActionLockdown: policy,
+	}
+}
+
+// decoyGenerator writes decoys from the JSON corpus at ZERO_DECOY_CORPUS,
+// or from the built-in one.
+func decoyGenerator() *decoy.Generator {
+	path := os.Getenv("ZERO_DECOY_CORPUS")
+	if path == "" {
+		return decoy.New(nil)
+	}
+	corpus, err := decoy.LoadCorpus(path)
+	if err != nil {
+		panic("zero: cannot load decoy corpus: " + err.Error())
+	}
+	fmt.Println("✓ Decoy Corpus Loaded")
+	return decoy.New(corpus)
+}
+
+// trustedProxies lists the reverse proxies (comma-separated CIDRs in
+// ZERO_TRUSTED_PROXIES) whose X-Forwarded-For access policies believe.
+func trustedProxies() []netip.Prefix {
+	var prefixes []netip.Prefix
+	for _, cidr := range strings.Split(os.Getenv("ZERO_TRUSTED_PROXIES"), ",") {
+		prefix, err := netip.ParsePrefix(strings.TrimSpace(cidr))
+		if err == nil {
+			prefixes = append(prefixes, prefix)
+		}
+	}
+	return prefixes
+}
@@ -1,33 +1,104 @@
 package normalize
 
-import "strings"
+import (
+	"encoding/binary"
+	"errors"
+	"strings"
+	"unicode/utf8"
 
-// Normalize ensures both strings are equal length by padding the shorter one.
-// Returns the two normalized strings.
-func Normalize(a, b string) (string, string) {
-	if len(a) == len(b) {
-		return a, b
+	"golang.org/x/text/unicode/norm"
+)
+
+// Buckets are the only plaintext sizes (in bytes, prefix included) a
+// reality is ever stored at. Both realities of a message are rounded up
+// to the same bucket, so neither their ciphertexts nor each other's
+// lengths give the real message length away.
+var Buckets = []int{256, 1024, 4096, 16384}
+
+// prefixLen is the size of the big-endian length header.
+const prefixLen = 4
+
+var (
+	ErrTooLong    = errors.New("normalize: text exceeds largest bucket")
+	ErrBadPadding = errors.New("normalize: malformed padding")
+)
+
+// MaxLen is the largest cleaned text, in bytes, that fits a bucket.
+func MaxLen() int {
+	return Buckets[len(Buckets)-1] - prefixLen
+}
+
+// Clean replaces invalid UTF-8 and converts s to NFC, so visually
+// identical text always has the same encoding and length.
+func Clean(s string) string {
+	return norm.NFC.String(strings.ToValidUTF8(s, string(utf8.RuneError)))
+}
+
+// BucketFor returns the smallest bucket that holds n bytes of text.
+func BucketFor(n int) (int, error) {
+	for _, b := range Buckets {
+		if n+prefixLen <= b {
+			return b, nil
+		}
 	}
+	return 0, ErrTooLong
+}
+
+// Pad encodes s as length || s || zeros, exactly size bytes long.
+func Pad(s string, size int) ([]byte, error) {
+	if len(s)+prefixLen > size {
+		return nil, ErrTooLong
+	}
+	out := make([]byte, size)
+	binary.BigEndian.PutUint32(out, uint32(len(s)))
+	copy(out[prefixLen:], s)
+	return out, nil
+}
 
-	targetLen := 0
-	if len(a) > len(b) {
-		targetLen = len(a)
-	} else {
-		targetLen = len(b)
+// Unpad strips the padding added by Pad and returns the original text.
+func Unpad(p []byte) (string, error) {
+	if len(p) < prefixLen {
+		return "", ErrBadPadding
 	}
+	n := binary.BigEndian.Uint32(p)
+	if uint64(n) > uint64(len(p)-prefixLen) {
+		return "", ErrBadPadding
+	}
+	return string(p[prefixLen : prefixLen+int(n)]), nil
+}
 
-	// Simple padding with spaces for MVP.
-	// In production, this would use standard padding schemes (PKCS#7) or random noise.
-	// But since this is application layer text, spaces are safe enough to hide length.
-	padA := targetLen - len(a)
-	padB := targetLen - len(b)
+// Grow re-pads a padded plaintext to a larger size. The zero padding and
+// length prefix stay valid, so Unpad still recovers the original text.
+// The result is always a fresh copy, never p itself, so callers can
+// zeroize it without wiping p.
+func Grow(p []byte, size int) []byte {
+	out := make([]byte, max(size, len(p)))
+	copy(out, p)
+	return out
+}
 
-	if padA > 0 {
-		a += strings.Repeat(" ", padA)
+// Normalize cleans every reality and pads them all to one shared bucket,
+// so no reality stands out by size. Returns the padded plaintexts, in
+// order, ready for encryption.
+func Normalize(realities ...string) ([][]byte, error) {
+	cleaned := make([]string, len(realities))
+	longest := 0
+	for i, r := range realities {
+		cleaned[i] = Clean(r)
+		if len(cleaned[i]) > longest {
+			longest = len(cleaned[i])
+		}
 	}
-	if padB > 0 {
-		b += strings.Repeat(" ", padB)
+	size, err := BucketFor(longest)
+	if err != nil {
+		return nil, err
 	}
 
-	return a, b
+	padded := make([][]byte, len(cleaned))
+	for i, r := range cleaned {
+		if padded[i], err = Pad(r, size); err != nil {
+			return nil, err
+		}
+	}
+	return padded, nil
 }
@@ -0,0 +1,331 @@
+package policy
+
+import (
+	"fmt"
+	"net/netip"
+	"strconv"
+	"strings"
+	"time"
+
+	"zero-system/window"
+)
+
+// token kinds
+const (
+	tokEOF = iota
+	tokIdent
+	tokNumber
+	tokString
+	tokLParen
+	tokRParen
+	tokComma
+	tokAnd
+	tokOr
+	tokNot
+)
+
+type token struct {
+	kind int
+	text string
+	pos  int
+}
+
+func lex(src string) ([]token, error) {
+	var tokens []token
+	for i := 0; i < len(src); {
+		c := src[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case c == '(':
+			tokens = append(tokens, token{tokLParen, "(", i})
+			i++
+		case c == ')':
+			tokens = append(tokens, token{tokRParen, ")", i})
+			i++
+		case c == ',':
+			tokens = append(tokens, token{tokComma, ",", i})
+			i++
+		case c == '!':
+			tokens = append(tokens, token{tokNot, "!", i})
+			i++
+		case strings.HasPrefix(src[i:], "&&"):
+			tokens = append(tokens, token{tokAnd, "&&", i})
+			i += 2
+		case strings.HasPrefix(src[i:], "||"):
+			tokens = append(tokens, token{tokOr, "||", i})
+			i += 2
+		case c == '"':
+			end := strings.IndexByte(src[i+1:], '"')
+			if end < 0 {
+				return nil, fmt.Errorf("policy: unterminated string at %d", i)
+			}
+			tokens = append(tokens, token{tokString, src[i+1 : i+1+end], i})
+			i += end + 2
+		case c == '-' || c == '.' || (c >= '0' && c <= '9'):
+			start := i
+			for i++; i < len(src) && (src[i] == '.' || (src[i] >= '0' && src[i] <= '9')); i++ {
+			}
+			tokens = append(tokens, token{tokNumber, src[start:i], start})
+		case c == '_' || (c|0x20 >= 'a' && c|0x20 <= 'z'):
+			start := i
+			for i++; i < len(src) && (src[i] == '_' || (src[i]|0x20 >= 'a' && src[i]|0x20 <= 'z')); i++ {
+			}
+			tokens = append(tokens, token{tokIdent, src[start:i], start})
+		default:
+			return nil, fmt.Errorf("policy: unexpected %q at %d", c, i)
+		}
+	}
+	return append(tokens, token{tokEOF, "", len(src)}), nil
+}
+
+type parser struct {
+	tokens []token
+	pos    int
+	nodes  int
+	policy *Policy
+}
+
+// Compile parses and checks a policy. An empty source compiles to nil,
+// which allows everything.
+func Compile(src string) (*Policy, error) {
+	if strings.TrimSpace(src) == "" {
+		return nil, nil
+	}
+	if len(src) > MaxSource {
+		return nil, ErrTooComplex
+	}
+	tokens, err := lex(src)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &parser{tokens: tokens, policy: &Policy{}}
+	root, err := p.or()
+	if err != nil {
+		return nil, err
+	}
+	if t := p.peek(); t.kind != tokEOF {
+		return nil, fmt.Errorf("policy: unexpected %q at %d", t.text, t.pos)
+	}
+	p.policy.root = root
+	return p.policy, nil
+}
+
+func (p *parser) peek() token { return p.tokens[p.pos] }
+
+func (p *parser) next() token {
+	t := p.tokens[p.pos]
+	if t.kind != tokEOF {
+		p.pos++
+	}
+	return t
+}
+
+func (p *parser) expect(kind int, what string) (token, error) {
+	t := p.next()
+	if t.kind != kind {
+		return t, fmt.Errorf("policy: expected %s at %d", what, t.pos)
+	}
+	return t, nil
+}
+
+// count enforces MaxNodes as the tree grows.
+func (p *parser) count() error {
+	p.nodes++
+	if p.nodes > MaxNodes {
+		return ErrTooComplex
+	}
+	return nil
+}
+
+func (p *parser) or() (node, error) {
+	left, err := p.and()
+	for err == nil && p.peek().kind == tokOr {
+		p.next()
+		var right node
+		if right, err = p.and(); err == nil {
+			err = p.count()
+			left = &orNode{left, right}
+		}
+	}
+	return left, err
+}
+
+func (p *parser) and() (node, error) {
+	left, err := p.unary()
+	for err == nil && p.peek().kind == tokAnd {
+		p.next()
+		var right node
+		if right, err = p.unary(); err == nil {
+			err = p.count()
+			left = &andNode{left, right}
+		}
+	}
+	return left, err
+}
+
+func (p *parser) unary() (node, error) {
+	if p.peek().kind == tokNot {
+		p.next()
+		inner, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		return &notNode{inner}, p.count()
+	}
+	return p.primary()
+}
+
+func (p *parser) primary() (node, error) {
+	t := p.next()
+	switch t.kind {
+	case tokLParen:
+		inner, err := p.or()
+		if err != nil {
+			return nil, err
+		}
+		_, err = p.expect(tokRParen, "')'")
+		return inner, err
+	case tokIdent:
+		if err := p.count(); err != nil {
+			return nil, err
+		}
+		switch t.text {
+		case "true":
+			return constNode(1), nil
+		case "false":
+			return constNode(0), nil
+		}
+		args, err := p.args()
+		if err != nil {
+			return nil, err
+		}
+		return p.predicate(t, args)
+	}
+	return nil, fmt.Errorf("policy: unexpected %q at %d", t.text, t.pos)
+}
+
+func (p *parser) args() ([]token, error) {
+	if _, err := p.expect(tokLParen, "'('"); err != nil {
+		return nil, err
+	}
+	var args []token
+	if p.peek().kind == tokRParen {
+		p.next()
+		return args, nil
+	}
+	for {
+		t := p.next()
+		if t.kind != tokNumber && t.kind != tokString {
+			return nil, fmt.Errorf("policy: expected argument at %d", t.pos)
+		}
+		args = append(args, t)
+		if sep := p.next(); sep.kind == tokRParen {
+			return args, nil
+		} else if sep.kind != tokComma {
+			return nil, fmt.Errorf("policy: expected ',' or ')' at %d", sep.pos)
+		}
+	}
+}
+
+func (p *parser) predicate(name token, args []token) (node, error) {
+	bad := func() (node, error) {
+		return nil, fmt.Errorf("policy: bad arguments to %s() at %d", name.text, name.pos)
+	}
+	numbers := func(n int) ([]float64, bool) {
+		if len(args) != n {
+			return nil, false
+		}
+		out := make([]float64, n)
+		for i, a := range args {
+			f, err := strconv.ParseFloat(a.text, 64)
+			if a.kind != tokNumber || err != nil {
+				return nil, false
+			}
+			out[i] = f
+		}
+		return out, true
+	}
+	strs := func(min, max int) ([]string, bool) {
+		if len(args) < min || len(args) > max {
+			return nil, false
+		}
+		out := make([]string, len(args))
+		for i, a := range args {
+			if a.kind != tokString {
+				return nil, false
+			}
+			out[i] = a.text
+		}
+		return out, true
+	}
+
+	switch name.text {
+	case "geo":
+		n, ok := numbers(3)
+		if !ok || n[0] < -90 || n[0] > 90 || n[1] < -180 || n[1] > 180 || n[2] <= 0 {
+			return bad()
+		}
+		return &geoNode{lat: n[0], long: n[1], radiusKm: n[2]}, nil
+
+	case "daily":
+		s, ok := strs(2, 3)
+		if !ok {
+			return bad()
+		}
+		start, errStart := window.ParseClock(s[0])
+		end, errEnd := window.ParseClock(s[1])
+		loc := time.UTC
+		if len(s) == 3 {
+			var err error
+			if loc, err = time.LoadLocation(s[2]); err != nil {
+				return bad()
+			}
+		}
+		if errStart != nil || errEnd != nil || start == end {
+			return bad()
+		}
+		return &dailyNode{window: window.Daily{Start: start, End: end}, loc: loc}, nil
+
+	case "between":
+		s, ok := strs(2, 2)
+		if !ok {
+			return bad()
+		}
+		from, errFrom := time.Parse(time.RFC3339, s[0])
+		to, errTo := time.Parse(time.RFC3339, s[1])
+		if errFrom != nil || errTo != nil || !to.After(from) {
+			return bad()
+		}
+		return &betweenNode{window: window.Interval{From: from, To: to}}, nil
+
+	case "cidr":
+		s, ok := strs(1, 1)
+		if !ok {
+			return bad()
+		}
+		prefix, err := netip.ParsePrefix(s[0])
+		if err != nil {
+			return bad()
+		}
+		return &cidrNode{prefix: prefix.Masked()}, nil
+
+	case "pow":
+		n, ok := numbers(1)
+		if !ok || n[0] < 1 || n[0] > 32 || n[0] != float64(int(n[0])) {
+			return bad()
+		}
+		return &powNode{bits: int(n[0])}, nil
+
+	case "views":
+		n, ok := numbers(1)
+		if !ok || n[0] < 1 || n[0] != float64(int(n[0])) {
+			return bad()
+		}
+		v := &viewsNode{limit: int(n[0])}
+		p.policy.views = append(p.policy.views, v)
+		return v, nil
+	}
+	return nil, fmt.Errorf("policy: unknown predicate %s() at %d", name.text, name.pos)
+}
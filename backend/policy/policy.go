@@ -0,0 +1,151 @@
+package policy
+
+import (
+	"errors"
+	"math"
+	"net/netip"
+	"time"
+
+	"zero-system/window"
+)
+
+// Access policies for entries.
+//
+// A policy is a boolean expression over predicates, compiled once at send
+// time and evaluated on every read:
+//
+//	geo(52.52, 13.40, 5) && daily("08:00", "08:15", "Europe/Berlin")
+//	(cidr("10.0.0.0/8") || pow(22)) && views(3)
+//
+// Predicates:
+//
+//	geo(lat, long, radiusKm)        reader's reported location within radius
+//	daily("HH:MM", "HH:MM"[, zone]) daily window, wrapping past midnight
+//	between(from, to)               one-shot window, RFC 3339 instants
+//	cidr("prefix")                  reader's network address
+//	pow(bits)                       reader solved a puzzle of at least bits
+//	views(n)                        fewer than n reads so far
+//
+// Operators are !, && and || with the usual precedence, plus parentheses,
+// true and false. Evaluation never short-circuits: every predicate is
+// computed on every read, so timing does not depend on which one failed.
+
+const (
+	MaxSource = 1024 // Bytes of policy text
+	MaxNodes  = 64   // Predicates and operators
+)
+
+var ErrTooComplex = errors.New("policy: too long or too complex")
+
+// Request is what a read is judged on.
+type Request struct {
+	Now         time.Time
+	HasLocation bool
+	Lat, Long   float64
+	Addr        netip.Addr // Client address; zero if unknown
+	Work        int        // Bits of a puzzle solved with this read, 0 if none
+}
+
+// Policy is a compiled access policy. Its view counters make it stateful:
+// callers must serialize Allow and Consume (the store lock does).
+type Policy struct {
+	root  node
+	views []*viewsNode
+}
+
+// Allow reports whether req may read. A nil policy allows everything.
+func (p *Policy) Allow(req Request) bool {
+	if p == nil {
+		return true
+	}
+	return p.root.eval(&req) == 1
+}
+
+// Consume counts one successful read against every views() predicate.
+func (p *Policy) Consume() {
+	if p == nil {
+		return
+	}
+	for _, v := range p.views {
+		v.used++
+	}
+}
+
+// node evaluates to 1 (true) or 0 (false).
+type node interface {
+	eval(req *Request) int
+}
+
+type andNode struct{ left, right node }
+
+func (n *andNode) eval(req *Request) int { return n.left.eval(req) & n.right.eval(req) }
+
+type orNode struct{ left, right node }
+
+func (n *orNode) eval(req *Request) int { return n.left.eval(req) | n.right.eval(req) }
+
+type notNode struct{ inner node }
+
+func (n *notNode) eval(req *Request) int { return 1 ^ n.inner.eval(req) }
+
+type constNode int
+
+func (n constNode) eval(*Request) int { return int(n) }
+
+type geoNode struct{ lat, long, radiusKm float64 }
+
+func (n *geoNode) eval(req *Request) int {
+	d := haversineKm(n.lat, n.long, req.Lat, req.Long)
+	return bit(req.HasLocation) & bit(d <= n.radiusKm)
+}
+
+type dailyNode struct {
+	window window.Daily
+	loc    *time.Location
+}
+
+func (n *dailyNode) eval(req *Request) int {
+	return bit(n.window.Contains(req.Now, n.loc))
+}
+
+type betweenNode struct{ window window.Interval }
+
+func (n *betweenNode) eval(req *Request) int {
+	return bit(n.window.Contains(req.Now))
+}
+
+type cidrNode struct{ prefix netip.Prefix }
+
+func (n *cidrNode) eval(req *Request) int {
+	return bit(n.prefix.Contains(req.Addr.Unmap()))
+}
+
+type powNode struct{ bits int }
+
+func (n *powNode) eval(req *Request) int {
+	return bit(req.Work >= n.bits)
+}
+
+type viewsNode struct{ limit, used int }
+
+func (n *viewsNode) eval(*Request) int {
+	return bit(n.used < n.limit)
+}
+
+func bit(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// haversineKm is the great-circle distance between two points.
+func haversineKm(lat1, long1, lat2, long2 float64) float64 {
+	const earthRadiusKm = 6371.0
+	rad := math.Pi / 180
+	dLat := (lat2 - lat1) * rad
+	dLong := (long2 - long1) * rad
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(lat1*rad)*math.Cos(lat2*rad)*math.Sin(dLong/2)*math.Sin(dLong/2)
+	return 2 * earthRadiusKm * math.Asin(math.Sqrt(a))
+}
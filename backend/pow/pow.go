@@ -0,0 +1,188 @@
+package pow
+
+import (
+	"container/heap"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"io"
+	"math"
+	"math/bits"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/awnumar/memguard"
+
+	"zero-system/clock"
+)
+
+// Hashcash-style client puzzles.
+// A challenge is self-describing and authenticated with an HMAC, so the
+// server keeps no per-challenge state until a solution is spent:
+//
+//	challenge = base64url(expiry(8) || difficulty(1) || nonce(16) || mac(32))
+//	solution  = any string s with SHA-256(challenge ":" s) starting with
+//	            `difficulty` zero bits
+const (
+	nonceLen     = 16
+	macLen       = sha256.Size
+	challengeLen = 8 + 1 + nonceLen + macLen
+)
+
+// Issuer hands out and verifies challenges.
+type Issuer struct {
+	key   *memguard.LockedBuffer // HMAC key, never leaves secure memory
+	ttl   time.Duration
+	clock clock.Clock
+
+	mu       sync.Mutex
+	spent    map[string]bool // Solved challenges until they expire
+	expiries spentQueue      // The same challenges, soonest expiry first
+}
+
+// spentChallenge is a solved challenge and the time it expires.
+type spentChallenge struct {
+	challenge string
+	expiry    time.Time
+}
+
+// spentQueue is a min-heap of spent challenges ordered by expiry, so that
+// forgetting the expired ones never scans the live ones.
+type spentQueue []spentChallenge
+
+func (q spentQueue) Len() int           { return len(q) }
+func (q spentQueue) Less(i, j int) bool { return q[i].expiry.Before(q[j].expiry) }
+func (q spentQueue) Swap(i, j int)      { q[i], q[j] = q[j], q[i] }
+
+func (q *spentQueue) Push(x any) { *q = append(*q, x.(spentChallenge)) }
+
+func (q *spentQueue) Pop() any {
+	old := *q
+	n := len(old)
+	item := old[n-1]
+	*q = old[:n-1]
+	return item
+}
+
+// NewIssuer creates an issuer whose challenges stay valid for ttl.
+func NewIssuer(clk clock.Clock, ttl time.Duration) *Issuer {
+	if clk == nil {
+		clk = clock.Real{}
+	}
+	return &Issuer{
+		key:   memguard.NewBufferRandom(32),
+		ttl:   ttl,
+		clock: clk,
+		spent: make(map[string]bool),
+	}
+}
+
+// Challenge issues a new puzzle of the given difficulty (leading zero bits).
+func (i *Issuer) Challenge(difficulty int) string {
+	raw := make([]byte, challengeLen)
+	binary.BigEndian.PutUint64(raw, uint64(i.clock.Now().Add(i.ttl).Unix()))
+	raw[8] = byte(difficulty)
+	io.ReadFull(rand.Reader, raw[9:9+nonceLen])
+	if i.key.IsAlive() {
+		copy(raw[9+nonceLen:], i.mac(raw[:9+nonceLen]))
+	}
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+// Verify checks that solution solves challenge, that the challenge was
+// issued here, is unexpired and asks for at least minDifficulty bits.
+// A solved challenge is spent and cannot be replayed.
+func (i *Issuer) Verify(challenge, solution string, minDifficulty int) bool {
+	raw, err := base64.RawURLEncoding.DecodeString(challenge)
+	if err != nil || len(raw) != challengeLen || !i.key.IsAlive() {
+		return false
+	}
+	if !hmac.Equal(raw[9+nonceLen:], i.mac(raw[:9+nonceLen])) {
+		return false
+	}
+	expiry := time.Unix(int64(binary.BigEndian.Uint64(raw)), 0)
+	now := i.clock.Now()
+	if now.After(expiry) {
+		return false
+	}
+	difficulty := int(raw[8])
+	if difficulty < minDifficulty || !Check(challenge, solution, difficulty) {
+		return false
+	}
+
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	if i.spent[challenge] {
+		return false
+	}
+	for len(i.expiries) > 0 && now.After(i.expiries[0].expiry) {
+		delete(i.spent, heap.Pop(&i.expiries).(spentChallenge).challenge)
+	}
+	i.spent[challenge] = true
+	heap.Push(&i.expiries, spentChallenge{challenge: challenge, expiry: expiry})
+	return true
+}
+
+// Close destroys the HMAC key. Outstanding challenges become invalid.
+func (i *Issuer) Close() {
+	i.key.Destroy()
+}
+
+// mac must only be called while the key is alive.
+func (i *Issuer) mac(data []byte) []byte {
+	m := hmac.New(sha256.New, i.key.Bytes())
+	m.Write(data)
+	return m.Sum(nil)
+}
+
+// Check reports whether solution has the required leading zero bits.
+func Check(challenge, solution string, difficulty int) bool {
+	sum := sha256.Sum256([]byte(challenge + ":" + solution))
+	zeros := 0
+	for _, b := range sum {
+		if b != 0 {
+			zeros += bits.LeadingZeros8(b)
+			break
+		}
+		zeros += 8
+	}
+	return zeros >= difficulty
+}
+
+// Solve brute-forces a solution. It is the client half of the protocol.
+func Solve(challenge string) string {
+	difficulty := Difficulty(challenge)
+	if difficulty == 0 {
+		return ""
+	}
+	for n := uint64(0); ; n++ {
+		solution := strconv.FormatUint(n, 16)
+		if Check(challenge, solution, difficulty) {
+			return solution
+		}
+	}
+}
+
+// Scale maps pressure in [0, 1] onto a difficulty between base and max bits.
+func Scale(base, max int, pressure float64) int {
+	if pressure <= 0 || max <= base {
+		return base
+	}
+	if pressure > 1 {
+		pressure = 1
+	}
+	return base + int(math.Round(float64(max-base)*pressure))
+}
+
+// Difficulty returns the bits a challenge asks for, 0 if it is malformed.
+// It does not check the challenge's authenticity.
+func Difficulty(challenge string) int {
+	raw, err := base64.RawURLEncoding.DecodeString(challenge)
+	if err != nil || len(raw) != challengeLen {
+		return 0
+	}
+	return int(raw[8])
+}
@@ -5,6 +5,8 @@ import (
 	"strings"
 	"sync"
 	"time"
+
+	"zero-system/clock"
 )
 
 // Client holds the state for a single IP
@@ -20,19 +22,30 @@ type Limiter struct {
 	rate        float64 // Tokens per second
 	burst       float64 // Maximum bucket size
 	cleanupTick time.Duration
+
+	clock        clock.Clock
+	cleanupTimer clock.Timer
+	closed       bool
 }
 
 // NewLimiter creates a new rate limiter.
 // rate = requests per second (e.g., 0.5 for 1 request every 2 seconds)
 // burst = max burst (e.g., 5)
-func NewLimiter(rate float64, burst float64) *Limiter {
+// clk drives refills and cleanup (the wall clock if nil).
+func NewLimiter(rate float64, burst float64, clk clock.Clock) *Limiter {
+	if clk == nil {
+		clk = clock.Real{}
+	}
 	l := &Limiter{
 		clients:     make(map[string]*Client),
 		rate:        rate,
 		burst:       burst,
 		cleanupTick: 5 * time.Minute, // Clean up stale IPs every 5 mins
+		clock:       clk,
 	}
-	go l.cleanupLoop()
+	l.mu.Lock()
+	l.cleanupTimer = clk.AfterFunc(l.cleanupTick, l.cleanup)
+	l.mu.Unlock()
 	return l
 }
 
@@ -41,7 +54,7 @@ func (l *Limiter) Allow(ip string) bool {
 	defer l.mu.Unlock()
 
 	client, exists := l.clients[ip]
-	now := time.Now()
+	now := l.clock.Now()
 
 	if !exists {
 		client = &Client{
@@ -69,38 +82,38 @@ func (l *Limiter) Allow(ip string) bool {
 	return false
 }
 
-// cleanupLoop removes old entries to prevent memory leaks from casual scanning
-func (l *Limiter) cleanupLoop() {
-	ticker := time.NewTicker(l.cleanupTick)
-	for range ticker.C {
-		l.mu.Lock()
-		now := time.Now()
-		for ip, client := range l.clients {
-			// If not seen for 10 mins, remove
-			if now.Sub(client.lastRefill) > 10*time.Minute {
-				delete(l.clients, ip)
-			}
+// cleanup removes old entries to prevent memory leaks from casual scanning
+func (l *Limiter) cleanup() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.closed {
+		return
+	}
+	now := l.clock.Now()
+	for ip, client := range l.clients {
+		// If not seen for 10 mins, remove
+		if now.Sub(client.lastRefill) > 10*time.Minute {
+			delete(l.clients, ip)
 		}
-		l.mu.Unlock()
 	}
+	l.cleanupTimer.Reset(l.cleanupTick)
+}
+
+// Close disarms the cleanup timer. The limiter must not be used afterwards.
+func (l *Limiter) Close() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.closed {
+		return
+	}
+	l.closed = true
+	l.cleanupTimer.Stop()
 }
 
 // Middleware wraps an http.HandlerFunc with rate limiting
 func (l *Limiter) Middleware(next http.HandlerFunc) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		// Extract IP (Naive implementation, use X-Forwarded-For if behind proxy)
-		ip := r.RemoteAddr
-		// Strip port if present
-		if idx := strings.LastIndex(ip, ":"); idx != -1 {
-			ip = ip[:idx]
-		}
-
-		// X-Forwarded-For support for when deployed behind Caddy/Nginx
-		if fwd := r.Header.Get("X-Forwarded-For"); fwd != "" {
-			ip = fwd
-		}
-
-		if !l.Allow(ip) {
+		if !l.Allow(ClientKey(r)) {
 			http.Error(w, "Too Many Requests", http.StatusTooManyRequests)
 			return
 		}
@@ -108,3 +121,19 @@ func (l *Limiter) Middleware(next http.HandlerFunc) http.HandlerFunc {
 		next(w, r)
 	}
 }
+
+// ClientKey identifies the caller of r for per-client accounting.
+func ClientKey(r *http.Request) string {
+	// Extract IP (Naive implementation, use X-Forwarded-For if behind proxy)
+	ip := r.RemoteAddr
+	// Strip port if present
+	if idx := strings.LastIndex(ip, ":"); idx != -1 {
+		ip = ip[:idx]
+	}
+
+	// X-Forwarded-For support for when deployed behind Caddy/Nginx
+	if fwd := r.Header.Get("X-Forwarded-For"); fwd != "" {
+		ip = fwd
+	}
+	return ip
+}
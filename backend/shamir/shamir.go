@@ -0,0 +1,123 @@
+package shamir
+
+import (
+	"crypto/rand"
+	"errors"
+
+	"zero-system/crypto"
+)
+
+// Shamir secret sharing over GF(2^8), byte by byte.
+// A share is its x coordinate followed by one y byte per secret byte:
+//
+//	share = x(1) || y(len(secret))
+//
+// Any k distinct shares recover the secret; fewer reveal nothing about it.
+
+var (
+	ErrParams      = errors.New("shamir: need 2 <= k <= n <= 255")
+	ErrShares      = errors.New("shamir: malformed or mismatched shares")
+	ErrDuplicateX  = errors.New("shamir: duplicate share")
+	ErrEmptySecret = errors.New("shamir: empty secret")
+)
+
+// Split cuts secret into n shares, any k of which recover it.
+func Split(secret []byte, n, k int) ([][]byte, error) {
+	if k < 2 || k > n || n > 255 {
+		return nil, ErrParams
+	}
+	if len(secret) == 0 {
+		return nil, ErrEmptySecret
+	}
+
+	shares := make([][]byte, n)
+	for i := range shares {
+		shares[i] = make([]byte, 1+len(secret))
+		shares[i][0] = byte(i + 1) // x = 0 would be the secret itself
+	}
+
+	// One random polynomial of degree k-1 per secret byte, constant term
+	// the secret byte.
+	coeffs := make([]byte, k)
+	defer crypto.Zeroize(coeffs)
+	for j, b := range secret {
+		coeffs[0] = b
+		if _, err := rand.Read(coeffs[1:]); err != nil {
+			return nil, err
+		}
+		for _, share := range shares {
+			share[1+j] = evaluate(coeffs, share[0])
+		}
+	}
+	return shares, nil
+}
+
+// Combine recovers the secret from k or more shares of the same split.
+// Shares from different splits yield garbage, not an error.
+func Combine(shares [][]byte) ([]byte, error) {
+	if len(shares) < 2 {
+		return nil, ErrShares
+	}
+	size := len(shares[0])
+	seen := make(map[byte]bool, len(shares))
+	for _, share := range shares {
+		if len(share) != size || size < 2 || share[0] == 0 {
+			return nil, ErrShares
+		}
+		if seen[share[0]] {
+			return nil, ErrDuplicateX
+		}
+		seen[share[0]] = true
+	}
+
+	// Lagrange interpolation at x = 0.
+	secret := make([]byte, size-1)
+	for i, si := range shares {
+		basis := byte(1)
+		for j, sj := range shares {
+			if i != j {
+				basis = mul(basis, div(sj[0], sj[0]^si[0]))
+			}
+		}
+		for b := range secret {
+			secret[b] ^= mul(si[1+b], basis)
+		}
+	}
+	return secret, nil
+}
+
+// evaluate computes the polynomial at x with Horner's rule.
+func evaluate(coeffs []byte, x byte) byte {
+	var y byte
+	for i := len(coeffs) - 1; i >= 0; i-- {
+		y = mul(y, x) ^ coeffs[i]
+	}
+	return y
+}
+
+// GF(2^8) with the AES polynomial x^8 + x^4 + x^3 + x + 1.
+// Multiplication avoids table lookups indexed by secret data.
+func mul(a, b byte) byte {
+	var p byte
+	for i := 0; i < 8; i++ {
+		mask := -(b & 1)
+		p ^= a & mask
+		carry := -(a >> 7)
+		a = a<<1 ^ 0x1b&carry
+		b >>= 1
+	}
+	return p
+}
+
+// inverse is a^254, which is a^-1 for a != 0.
+func inverse(a byte) byte {
+	result := byte(1)
+	for i := 0; i < 254; i++ {
+		result = mul(result, a)
+	}
+	return result
+}
+
+func div(a, b byte) byte {
+	return mul(a, inverse(b))
+}
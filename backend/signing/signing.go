@@ -0,0 +1,177 @@
+package signing
+
+import (
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"sync"
+	"time"
+
+	"github.com/awnumar/memguard"
+
+	"zero-system/clock"
+	"zero-system/normalize"
+)
+
+// Sender authenticity signatures.
+//
+// A sender signs every reality of a note with Ed25519 over
+//
+//	"zero-signature" 0 slot 0 expiry(8, Unix seconds) 0 text
+//
+// where slot is the receiver's base RX and text the reality as
+// normalize.Clean returns it. Every reality carries a signature of the
+// same shape under the same key, so a decoy looks exactly as authentic as
+// the truth. Signing happens client-side, or server-side with a key the
+// Registry keeps for a sender token.
+
+const domain = "zero-signature"
+
+// A Registry holds at most MaxSigners seeds, each in its own locked
+// buffer. A key that has been neither registered nor used to sign for
+// SignerTTL is destroyed, so a full registry frees up again; its sender
+// gets a new key, under a new fingerprint, on the next registration.
+const (
+	MaxSigners    = 256
+	SignerTTL     = 24 * time.Hour
+	sweepInterval = time.Hour
+)
+
+// Message is what a sender signs for one reality.
+func Message(slot string, expiry time.Time, text string) []byte {
+	text = normalize.Clean(text)
+	msg := make([]byte, 0, len(domain)+len(slot)+len(text)+11)
+	msg = append(msg, domain...)
+	msg = append(msg, 0)
+	msg = append(msg, slot...)
+	msg = append(msg, 0)
+	msg = binary.BigEndian.AppendUint64(msg, uint64(expiry.Unix()))
+	msg = append(msg, 0)
+	return append(msg, text...)
+}
+
+// Sign is the client half of the protocol.
+func Sign(priv ed25519.PrivateKey, slot string, expiry time.Time, text string) []byte {
+	return ed25519.Sign(priv, Message(slot, expiry, text))
+}
+
+// Verify checks one reality's signature.
+func Verify(pub ed25519.PublicKey, slot string, expiry time.Time, text string, sig []byte) bool {
+	return len(pub) == ed25519.PublicKeySize && ed25519.Verify(pub, Message(slot, expiry, text), sig)
+}
+
+// Fingerprint names a signer key: the first 16 bytes of its SHA-256, in hex.
+func Fingerprint(pub ed25519.PublicKey) string {
+	sum := sha256.Sum256(pub)
+	return hex.EncodeToString(sum[:16])
+}
+
+// Registry holds sender keys generated on behalf of sender tokens. Only
+// token digests are kept, and private keys only ever live in memguard.
+type Registry struct {
+	mu   sync.Mutex
+	keys map[[sha256.Size]byte]*signer
+
+	clock      clock.Clock
+	sweepTimer clock.Timer
+	closed     bool
+}
+
+type signer struct {
+	seed *memguard.LockedBuffer // Ed25519 seed
+	used time.Time
+}
+
+func NewRegistry(clk clock.Clock) *Registry {
+	if clk == nil {
+		clk = clock.Real{}
+	}
+	r := &Registry{keys: make(map[[sha256.Size]byte]*signer), clock: clk}
+	r.mu.Lock()
+	r.sweepTimer = clk.AfterFunc(sweepInterval, r.sweep)
+	r.mu.Unlock()
+	return r
+}
+
+// Register returns the public key kept for tx, generating it on first
+// use. ok is false once the registry is full.
+func (r *Registry) Register(tx string) (pub ed25519.PublicKey, ok bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	digest := sha256.Sum256([]byte(tx))
+	k, exists := r.keys[digest]
+	if !exists {
+		if r.closed || len(r.keys) >= MaxSigners {
+			return nil, false
+		}
+		k = &signer{seed: memguard.NewBufferRandom(ed25519.SeedSize)}
+		r.keys[digest] = k
+	}
+	k.used = r.clock.Now()
+	return publicKey(k.seed), true
+}
+
+// Sign signs one reality with the key registered for tx.
+func (r *Registry) Sign(tx, slot string, expiry time.Time, text string) (pub ed25519.PublicKey, sig []byte, ok bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	k, exists := r.keys[sha256.Sum256([]byte(tx))]
+	if !exists {
+		return nil, nil, false
+	}
+	k.used = r.clock.Now()
+	priv := ed25519.NewKeyFromSeed(k.seed.Bytes())
+	defer memguard.WipeBytes(priv)
+	return publicKey(k.seed), ed25519.Sign(priv, Message(slot, expiry, text)), true
+}
+
+// Wipe destroys every registered key.
+func (r *Registry) Wipe() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.destroyKeys()
+}
+
+// destroyKeys must be called with r.mu held.
+func (r *Registry) destroyKeys() {
+	for _, k := range r.keys {
+		k.seed.Destroy()
+	}
+	r.keys = make(map[[sha256.Size]byte]*signer)
+}
+
+// sweep destroys keys idle for SignerTTL.
+func (r *Registry) sweep() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.closed {
+		return
+	}
+	now := r.clock.Now()
+	for digest, k := range r.keys {
+		if now.Sub(k.used) >= SignerTTL {
+			k.seed.Destroy()
+			delete(r.keys, digest)
+		}
+	}
+	r.sweepTimer.Reset(sweepInterval)
+}
+
+// Close destroys every key and disarms the sweep timer.
+func (r *Registry) Close() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.closed {
+		return
+	}
+	r.closed = true
+	r.sweepTimer.Stop()
+	r.destroyKeys()
+}
+
+func publicKey(seed *memguard.LockedBuffer) ed25519.PublicKey {
+	priv := ed25519.NewKeyFromSeed(seed.Bytes())
+	defer memguard.WipeBytes(priv)
+	return append(ed25519.PublicKey(nil), priv.Public().(ed25519.PublicKey)...)
+}
@@ -0,0 +1,189 @@
+package store
+
+import (
+	"errors"
+	"time"
+
+	"github.com/awnumar/memguard"
+
+	"zero-system/crypto"
+)
+
+const (
+	epochInterval = 1 * time.Hour // How often a fresh epoch key is minted
+	epochKeySize  = 32
+	epochNonceLen = 12
+)
+
+var errEpochRetired = errors.New("store: epoch key destroyed")
+
+// epoch is a generation of the store-wide wrapping key.
+// Every ciphertext saved while the epoch is current is sealed under its
+// key, which only ever lives in memguard. Destroying the key makes those
+// ciphertexts unrecoverable at once, regardless of what the GC still holds.
+type epoch struct {
+	id       uint64
+	key      *memguard.LockedBuffer
+	retireAt time.Time // Latest ExpiryTime sealed under this epoch
+}
+
+func newEpoch(id uint64) *epoch {
+	return &epoch{id: id, key: memguard.NewBufferRandom(epochKeySize)}
+}
+
+// seal wraps data as nonce || AES-GCM(epochKey, data).
+func (e *epoch) seal(data []byte) ([]byte, error) {
+	if !e.key.IsAlive() {
+		return nil, errEpochRetired
+	}
+	ciphertext, nonce, err := crypto.EncryptAESGCM(data, e.key.Bytes())
+	if err != nil {
+		return nil, err
+	}
+	return append(nonce, ciphertext...), nil
+}
+
+// open reverses seal. It fails once the epoch key has been destroyed.
+func (e *epoch) open(sealed []byte) ([]byte, error) {
+	if !e.key.IsAlive() {
+		return nil, errEpochRetired
+	}
+	if len(sealed) < epochNonceLen {
+		return nil, errors.New("store: sealed data too short")
+	}
+	return crypto.DecryptAESGCM(sealed[epochNonceLen:], e.key.Bytes(), sealed[:epochNonceLen])
+}
+
+// sealInPlace replaces *data with its sealed form and zeroizes the original.
+func (e *epoch) sealInPlace(data *[]byte) error {
+	if *data == nil {
+		return nil
+	}
+	sealed, err := e.seal(*data)
+	if err != nil {
+		return err
+	}
+	crypto.Zeroize(*data)
+	*data = sealed
+	return nil
+}
+
+func (e *epoch) destroy() {
+	e.key.Destroy()
+}
+
+// rotate mints a new current epoch and retires every older epoch whose
+// entries have all expired, then re-arms itself.
+func (s *MemoryStore) rotate() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return
+	}
+
+	now := s.clock.Now()
+	s.current = newEpoch(s.current.id + 1)
+	for id, e := range s.epochs {
+		if now.Before(e.retireAt) {
+			continue
+		}
+		e.destroy()
+		delete(s.epochs, id)
+	}
+	s.epochs[s.current.id] = s.current
+	s.epochTimer.Reset(epochInterval)
+}
+
+// destroyEpochs is the cryptographic half of a wipe: every epoch key is
+// destroyed, so every stored ciphertext becomes noise in constant time.
+// A fresh epoch is installed for entries saved afterwards.
+// Must be called with s.mu held.
+func (s *MemoryStore) destroyEpochs() {
+	for _, e := range s.epochs {
+		e.destroy()
+	}
+	next := uint64(1)
+	if s.current != nil {
+		next = s.current.id + 1
+	}
+	s.current = newEpoch(next)
+	s.epochs = map[uint64]*epoch{s.current.id: s.current}
+}
+
+// sealEntry wraps every reality of entry under the current epoch.
+// Must be called with s.mu held.
+func (s *MemoryStore) sealEntry(entry *SecureEntry) error {
+	for _, r := range append([]*MessageReality{entry.Duress}, entry.Realities...) {
+		if r == nil || r.Destroyed {
+			continue
+		}
+		if err := s.current.sealInPlace(&r.Ciphertext); err != nil {
+			return err
+		}
+		if err := s.current.sealInPlace(&r.Signature); err != nil {
+			return err
+		}
+		for i := range r.Pages {
+			if err := s.current.sealInPlace(&r.Pages[i].Ciphertext); err != nil {
+				return err
+			}
+		}
+	}
+	if entry.Deniable {
+		entry.packContainer()
+	}
+	entry.epoch = s.current
+	if entry.ExpiryTime.After(s.current.retireAt) {
+		s.current.retireAt = entry.ExpiryTime
+	}
+	return nil
+}
+
+// UnwrapSignature is Unwrap for the reality's sealed signature, nil if it
+// has none.
+// The caller must hold the store lock.
+func (s *MemoryStore) UnwrapSignature(entry *SecureEntry, r *MessageReality) ([]byte, error) {
+	if r != nil && r.Signature == nil && !r.Destroyed {
+		return nil, nil
+	}
+	if entry.epoch == nil || r == nil || r.Destroyed {
+		return nil, errEpochRetired
+	}
+	return entry.epoch.open(r.Signature)
+}
+
+// packContainer moves the sealed slots of a deniable entry into one blob
+// and points each reality at its slot.
+func (e *SecureEntry) packContainer() {
+	size := 0
+	for _, r := range e.Realities {
+		size += len(r.Ciphertext)
+	}
+	e.container = make([]byte, 0, size)
+	for _, r := range e.Realities {
+		start := len(e.container)
+		e.container = append(e.container, r.Ciphertext...)
+		crypto.Zeroize(r.Ciphertext)
+		r.Ciphertext = e.container[start:len(e.container):len(e.container)]
+		r.scrub = true
+	}
+}
+
+// Unwrap returns the reality's ciphertext as it was before Save sealed it
+// under the epoch key. It fails once the reality or its epoch is destroyed.
+// The caller must hold the store lock.
+func (s *MemoryStore) Unwrap(entry *SecureEntry, r *MessageReality) ([]byte, error) {
+	if entry.epoch == nil || r == nil || r.Destroyed {
+		return nil, errEpochRetired
+	}
+	return entry.epoch.open(r.Ciphertext)
+}
+
+// UnwrapPage is Unwrap for page i of a paged reality.
+// The caller must hold the store lock.
+func (s *MemoryStore) UnwrapPage(entry *SecureEntry, r *MessageReality, i int) ([]byte, error) {
+	if entry.epoch == nil || r == nil || r.Destroyed || i < 0 || i >= len(r.Pages) {
+		return nil, errEpochRetired
+	}
+	return entry.epoch.open(r.Pages[i].Ciphertext)
+}
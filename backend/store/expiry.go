@@ -0,0 +1,142 @@
+package store
+
+import (
+	"container/heap"
+	"crypto/sha256"
+	"time"
+)
+
+// expiryItem schedules the destruction of one entry, of the receipt an
+// expired entry left behind, of realities whose view window closes or of
+// an idle group, forgets a lapsed reply capability, or counts the view of
+// a paged read left open.
+type expiryItem struct {
+	entry    *SecureEntry
+	receipt  *receipt
+	group    *group
+	reply    *[sha256.Size]byte // Digest of a reply capability
+	view     *MessageReality    // Paged read to count as viewed
+	burn     []*MessageReality
+	deadline time.Time
+	index    int // Position in the heap, -1 once removed
+}
+
+// expiryQueue is a min-heap of entries ordered by deadline.
+// Only the head is ever inspected, so expiring k entries costs O(k log n)
+// no matter how many entries are live.
+type expiryQueue []*expiryItem
+
+func (q expiryQueue) Len() int { return len(q) }
+
+func (q expiryQueue) Less(i, j int) bool {
+	return q[i].deadline.Before(q[j].deadline)
+}
+
+func (q expiryQueue) Swap(i, j int) {
+	q[i], q[j] = q[j], q[i]
+	q[i].index = i
+	q[j].index = j
+}
+
+func (q *expiryQueue) Push(x any) {
+	item := x.(*expiryItem)
+	item.index = len(*q)
+	*q = append(*q, item)
+}
+
+func (q *expiryQueue) Pop() any {
+	old := *q
+	n := len(old)
+	item := old[n-1]
+	old[n-1] = nil
+	item.index = -1
+	*q = old[:n-1]
+	return item
+}
+
+// schedule registers entry for destruction at its ExpiryTime.
+// Must be called with s.mu held.
+func (s *MemoryStore) schedule(entry *SecureEntry) {
+	item := &expiryItem{entry: entry, deadline: entry.ExpiryTime}
+	entry.expiry = item
+	heap.Push(&s.expiries, item)
+	s.armExpiry()
+}
+
+// unschedule removes entry from the queue without destroying it.
+// Must be called with s.mu held.
+func (s *MemoryStore) unschedule(entry *SecureEntry) {
+	if entry.expiry == nil || entry.expiry.index < 0 {
+		return
+	}
+	heap.Remove(&s.expiries, entry.expiry.index)
+	entry.expiry = nil
+	s.armExpiry()
+}
+
+// armExpiry points the single expiry timer at the earliest deadline.
+// Must be called with s.mu held.
+func (s *MemoryStore) armExpiry() {
+	if s.closed {
+		return
+	}
+	if len(s.expiries) == 0 {
+		if s.expiryTimer != nil {
+			s.expiryTimer.Stop()
+		}
+		return
+	}
+
+	wait := s.expiries[0].deadline.Sub(s.clock.Now())
+	if wait < 0 {
+		wait = 0
+	}
+	if s.expiryTimer == nil {
+		s.expiryTimer = s.clock.AfterFunc(wait, s.expire)
+		return
+	}
+	s.expiryTimer.Reset(wait)
+}
+
+// expire destroys every entry, receipt and viewed reality whose deadline
+// has passed, then re-arms.
+func (s *MemoryStore) expire() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return
+	}
+
+	now := s.clock.Now()
+	for len(s.expiries) > 0 && !s.expiries[0].deadline.After(now) {
+		item := heap.Pop(&s.expiries).(*expiryItem)
+		if item.view != nil {
+			item.view.paging, item.view.served = nil, nil
+			s.View(item.view, item.burn...)
+			continue
+		}
+		if item.burn != nil {
+			s.Burn(item.burn...)
+			continue
+		}
+		if item.group != nil {
+			s.dropGroup(item.group)
+			continue
+		}
+		if item.reply != nil {
+			if s.replies[*item.reply].Equal(item.deadline) {
+				delete(s.replies, *item.reply)
+			}
+			continue
+		}
+		if item.receipt != nil {
+			if s.receipts[item.receipt.digest] == item.receipt {
+				delete(s.receipts, item.receipt.digest)
+			}
+			continue
+		}
+		item.entry.expiry = nil
+		s.drop(item.entry, StateExpired)
+	}
+	s.armExpiry()
+}
@@ -0,0 +1,311 @@
+package store
+
+import (
+	"container/heap"
+	"crypto/sha256"
+	"errors"
+	"slices"
+	"time"
+
+	"github.com/awnumar/memguard"
+)
+
+// Group channels.
+//
+// A group is reached through three kinds of capability, all held as
+// digests: one admin capability that changes the membership, one post
+// capability shared by every member, and one read credential per member.
+// Posts are sealed under the group key of the generation they were sent
+// in. Every membership change starts a new generation with a fresh key and
+// a fresh post capability, so removed members can neither post nor read
+// anything sent afterwards. Each member reads every post once, oldest
+// first, with independent burn state; a post is destroyed once every
+// member it was addressed to has read it, or when it expires.
+//
+// Groups live in RAM only. A group nobody has posted to or rotated for
+// GroupTTL is destroyed along with its keys and posts, and Wipe destroys
+// them all. Every group key is a locked buffer, so the store holds at most
+// MaxGroupKeys of them across all groups and generations; past that,
+// groups can neither be created nor rotated until old posts are read or
+// expire, or idle groups are destroyed.
+
+const (
+	groupKeySize    = 32
+	MaxGroupMembers = 32
+	MaxGroupKeys    = 512 // Each key pins a page of locked memory
+	GroupTTL        = 24 * time.Hour
+)
+
+var errGroup = errors.New("store: unknown group, full group or capability in use")
+
+type group struct {
+	expiry     *expiryItem // Destroys the group once it has been idle for GroupTTL
+	admin      [sha256.Size]byte
+	post       [sha256.Size]byte // Current post capability
+	generation int
+	keys       map[int]*memguard.LockedBuffer // Generations with live posts, and the current one
+	members    map[[sha256.Size]byte]bool
+	posts      []*groupPost // Oldest first
+}
+
+type groupPost struct {
+	entry      *SecureEntry
+	generation int
+	pending    map[[sha256.Size]byte]bool // Members yet to read it
+}
+
+// CreateGroup registers a group with its admin and post capabilities and
+// the read credentials of its first members.
+func (s *MemoryStore) CreateGroup(admin, post string, members []string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(members) > MaxGroupMembers || s.groupKeys >= MaxGroupKeys ||
+		!s.groupCapabilitiesFree(append([]string{admin, post}, members...)) {
+		return errGroup
+	}
+
+	g := &group{
+		admin:   sha256.Sum256([]byte(admin)),
+		keys:    make(map[int]*memguard.LockedBuffer),
+		members: make(map[[sha256.Size]byte]bool),
+	}
+	s.groups[g.admin] = g
+	s.startGeneration(g, post)
+	for _, m := range members {
+		digest := sha256.Sum256([]byte(m))
+		g.members[digest] = true
+		s.groupMembers[digest] = g
+	}
+	s.keepGroup(g, time.Time{})
+	return nil
+}
+
+// RotateGroup removes and adds members of the group admin controls and
+// starts a new generation under post. Posts already sent stay readable by
+// the members that remain.
+func (s *MemoryStore) RotateGroup(admin, post string, remove, add []string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	g, exists := s.groups[sha256.Sum256([]byte(admin))]
+	if !exists {
+		return errGroup
+	}
+	s.pruneGroup(g)
+	if s.groupKeys >= MaxGroupKeys || !s.groupCapabilitiesFree(append([]string{post}, add...)) {
+		return errGroup
+	}
+	remaining := len(g.members)
+	for _, m := range remove {
+		if g.members[sha256.Sum256([]byte(m))] {
+			remaining--
+		}
+	}
+	if remaining+len(add) > MaxGroupMembers {
+		return errGroup
+	}
+
+	for _, m := range remove {
+		digest := sha256.Sum256([]byte(m))
+		if !g.members[digest] {
+			continue
+		}
+		delete(g.members, digest)
+		delete(s.groupMembers, digest)
+		for _, p := range g.posts {
+			delete(p.pending, digest)
+		}
+	}
+	for _, m := range add {
+		digest := sha256.Sum256([]byte(m))
+		g.members[digest] = true
+		s.groupMembers[digest] = g
+	}
+	delete(s.groupPosters, g.post)
+	s.startGeneration(g, post)
+	s.pruneGroup(g)
+	s.keepGroup(g, time.Time{})
+	return nil
+}
+
+// PostToGroup stores a post for every current member of the group post
+// addresses. seal encrypts it under the current group key, which it must
+// not retain.
+func (s *MemoryStore) PostToGroup(post string, expiry time.Time, seal func(key []byte) (*MessageReality, error)) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	g, exists := s.groupPosters[sha256.Sum256([]byte(post))]
+	if !exists || len(g.members) == 0 {
+		return errGroup
+	}
+	reality, err := seal(g.keys[g.generation].Bytes())
+	if err != nil {
+		return err
+	}
+
+	entry := &SecureEntry{Realities: []*MessageReality{reality}, ExpiryTime: expiry}
+	if err := s.sealEntry(entry); err != nil {
+		entry.destroy()
+		return err
+	}
+	p := &groupPost{entry: entry, generation: g.generation, pending: make(map[[sha256.Size]byte]bool, len(g.members))}
+	for m := range g.members {
+		p.pending[m] = true
+	}
+	g.posts = append(g.posts, p)
+	s.schedule(entry)
+	s.keepGroup(g, expiry)
+	return nil
+}
+
+// NextGroupPost returns member's oldest unread post and the group key it
+// was sealed under, valid until the lock is released.
+// The caller must hold the store lock.
+func (s *MemoryStore) NextGroupPost(member string) (*SecureEntry, []byte, bool) {
+	digest := sha256.Sum256([]byte(member))
+	g, exists := s.groupMembers[digest]
+	if !exists {
+		return nil, nil, false
+	}
+	s.pruneGroup(g)
+	now := s.clock.Now()
+	for _, p := range g.posts {
+		// The expiry timer may still be in flight; never hand out a stale post.
+		if p.pending[digest] && !now.After(p.entry.ExpiryTime) {
+			return p.entry, g.keys[p.generation].Bytes(), true
+		}
+	}
+	return nil, nil, false
+}
+
+// ConsumeGroupPost records that member has read entry, and destroys the
+// post once every member it was addressed to has.
+// The caller must hold the store lock.
+func (s *MemoryStore) ConsumeGroupPost(member string, entry *SecureEntry) {
+	digest := sha256.Sum256([]byte(member))
+	g, exists := s.groupMembers[digest]
+	if !exists {
+		return
+	}
+	for _, p := range g.posts {
+		if p.entry != entry {
+			continue
+		}
+		delete(p.pending, digest)
+		if len(p.pending) == 0 {
+			entry.Realities[0].Read = true
+			s.unschedule(entry)
+			s.drop(entry, StateRead)
+		}
+	}
+	s.pruneGroup(g)
+}
+
+// groupCapabilitiesFree reports whether none of caps is in use by any group.
+// Must be called with s.mu held.
+func (s *MemoryStore) groupCapabilitiesFree(caps []string) bool {
+	seen := make(map[[sha256.Size]byte]bool, len(caps))
+	for _, c := range caps {
+		digest := sha256.Sum256([]byte(c))
+		_, admin := s.groups[digest]
+		_, poster := s.groupPosters[digest]
+		_, member := s.groupMembers[digest]
+		if c == "" || admin || poster || member || seen[digest] {
+			return false
+		}
+		seen[digest] = true
+	}
+	return true
+}
+
+// startGeneration mints a fresh group key reachable through post.
+// Must be called with s.mu held.
+func (s *MemoryStore) startGeneration(g *group, post string) {
+	g.generation++
+	g.keys[g.generation] = memguard.NewBufferRandom(groupKeySize)
+	s.groupKeys++
+	g.post = sha256.Sum256([]byte(post))
+	s.groupPosters[g.post] = g
+}
+
+// pruneGroup forgets posts that were read by everyone or destroyed, and
+// destroys the keys of past generations without posts.
+// Must be called with s.mu held.
+func (s *MemoryStore) pruneGroup(g *group) {
+	g.posts = slices.DeleteFunc(g.posts, func(p *groupPost) bool {
+		if len(p.pending) > 0 && !p.entry.Realities[0].Destroyed {
+			return false
+		}
+		if !p.entry.Realities[0].Destroyed {
+			s.unschedule(p.entry)
+			s.drop(p.entry, StateDestroyed)
+		}
+		return true
+	})
+	live := map[int]bool{g.generation: true}
+	for _, p := range g.posts {
+		live[p.generation] = true
+	}
+	for generation, key := range g.keys {
+		if !live[generation] {
+			key.Destroy()
+			delete(g.keys, generation)
+			s.groupKeys--
+		}
+	}
+}
+
+// keepGroup pushes the destruction of g back to GroupTTL from now, or to
+// until if that is later: the expiry of a post it just received.
+// Must be called with s.mu held.
+func (s *MemoryStore) keepGroup(g *group, until time.Time) {
+	deadline := s.clock.Now().Add(GroupTTL)
+	if until.After(deadline) {
+		deadline = until
+	}
+	if g.expiry == nil {
+		g.expiry = &expiryItem{group: g, deadline: deadline}
+		heap.Push(&s.expiries, g.expiry)
+	} else if deadline.After(g.expiry.deadline) {
+		g.expiry.deadline = deadline
+		heap.Fix(&s.expiries, g.expiry.index)
+	}
+	s.armExpiry()
+}
+
+// dropGroup destroys g's keys and posts and forgets its capabilities.
+// Must be called with s.mu held.
+func (s *MemoryStore) dropGroup(g *group) {
+	for _, p := range g.posts {
+		if !p.entry.Realities[0].Destroyed {
+			s.unschedule(p.entry)
+			s.drop(p.entry, StateExpired)
+		}
+	}
+	for _, key := range g.keys {
+		key.Destroy()
+	}
+	s.groupKeys -= len(g.keys)
+	for m := range g.members {
+		delete(s.groupMembers, m)
+	}
+	delete(s.groupPosters, g.post)
+	delete(s.groups, g.admin)
+}
+
+// wipeGroups destroys every group key and post and forgets every group.
+// Must be called with s.mu held.
+func (s *MemoryStore) wipeGroups() {
+	for _, g := range s.groups {
+		for _, key := range g.keys {
+			key.Destroy()
+		}
+		for _, p := range g.posts {
+			p.entry.expiry = nil
+			p.entry.destroy()
+		}
+	}
+	s.groups = make(map[[sha256.Size]byte]*group)
+	s.groupPosters = make(map[[sha256.Size]byte]*group)
+	s.groupMembers = make(map[[sha256.Size]byte]*group)
+	s.groupKeys = 0
+}
@@ -0,0 +1,94 @@
+package store
+
+import (
+	"container/heap"
+	"crypto/sha256"
+	"slices"
+	"sort"
+	"time"
+)
+
+// Reality states reported by receipts.
+const (
+	StatePending   = "pending"   // Not read yet
+	StateRead      = "read"      // Burned by a read
+	StateExpired   = "expired"   // Unread when the entry expired
+	StateDestroyed = "destroyed" // Burned by recall, duress, overwrite or a wipe
+)
+
+// receiptGrace is how long a receipt outlives its entry, so the sender can
+// still observe how it ended. Panic and dead man wipes do not wait.
+const receiptGrace = 1 * time.Hour
+
+// receipt tracks the outcome of one send for its sender: one entry, or
+// every copy of a fan-out. While an entry is live its states are read off
+// the entry itself; once it is dropped they are frozen into states.
+type receipt struct {
+	digest  [sha256.Size]byte
+	entries []*SecureEntry // Live entries
+	states  []string       // Frozen states of dropped entries
+}
+
+// snapshot reports every reality of every entry, treating live ones past
+// their expiry as expired: the expiry timer may still be in flight.
+func (r *receipt) snapshot(now time.Time) []string {
+	states := append([]string(nil), r.states...)
+	for _, entry := range r.entries {
+		unread := StatePending
+		if now.After(entry.ExpiryTime) {
+			unread = StateExpired
+		}
+		for _, reality := range entry.Realities {
+			states = append(states, realityState(reality, unread))
+		}
+	}
+	return states
+}
+
+func realityState(r *MessageReality, unread string) string {
+	switch {
+	case r.Read:
+		return StateRead
+	case r.Destroyed:
+		return StateDestroyed
+	}
+	return unread
+}
+
+// retire freezes the states of entry into its receipt as it is dropped.
+// Unread realities end up as unread. Once its last entry is gone the
+// receipt is kept until the grace has passed.
+// Must be called with s.mu held.
+func (s *MemoryStore) retire(entry *SecureEntry, unread string) {
+	r := entry.receipt
+	if r == nil || !slices.Contains(r.entries, entry) {
+		return
+	}
+	for _, reality := range entry.Realities {
+		r.states = append(r.states, realityState(reality, unread))
+	}
+	r.entries = slices.DeleteFunc(r.entries, func(e *SecureEntry) bool { return e == entry })
+	if len(r.entries) > 0 {
+		return
+	}
+
+	item := &expiryItem{receipt: r, deadline: entry.ExpiryTime.Add(receiptGrace)}
+	heap.Push(&s.expiries, item)
+	s.armExpiry()
+}
+
+// Receipt returns the state of every reality of every entry the receipt
+// was issued for, sorted so that it never tells which reality was read.
+// ok is false for unknown, wiped and lapsed receipts.
+func (s *MemoryStore) Receipt(token string) (states []string, ok bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	r, exists := s.receipts[sha256.Sum256([]byte(token))]
+	if !exists {
+		return nil, false
+	}
+
+	states = r.snapshot(s.clock.Now())
+	sort.Strings(states)
+	return states, true
+}
@@ -0,0 +1,35 @@
+package store
+
+import (
+	"time"
+
+	"zero-system/window"
+)
+
+// Schedule restricts reads of an entry to pre-agreed check times.
+// An entry with a schedule is readable only while one of its windows is
+// open; outside them it is indistinguishable from a missing slot.
+type Schedule struct {
+	Location  *time.Location // Time zone of Daily (UTC if nil)
+	Daily     []window.Daily
+	Intervals []window.Interval // One-shot windows
+}
+
+// Open reports whether t falls inside a window. A nil schedule is always
+// open.
+func (s *Schedule) Open(t time.Time) bool {
+	if s == nil {
+		return true
+	}
+	for _, iv := range s.Intervals {
+		if iv.Contains(t) {
+			return true
+		}
+	}
+	for _, w := range s.Daily {
+		if w.Contains(t, s.Location) {
+			return true
+		}
+	}
+	return false
+}
@@ -1,15 +1,72 @@
 package store
 
 import (
+	"container/heap"
+	"crypto/rand"
+	"crypto/sha256"
+	"errors"
+	"slices"
+	"strings"
 	"sync"
 	"time"
+
+	"zero-system/clock"
+	"zero-system/crypto"
+	"zero-system/policy"
+)
+
+const (
+	deadManInterval       = 1 * time.Hour  // How often the Dead Man Switch is checked
+	DefaultDeadManTimeout = 24 * time.Hour // Inactivity that triggers a wipe
 )
 
+var errBatch = errors.New("store: mismatched or overlapping fan-out")
+
 // MessageReality holds the encrypted data for a single reality.
+// Realities too large for one response envelope carry Pages instead of
+// Ciphertext.
 type MessageReality struct {
 	Ciphertext []byte
 	Nonce      []byte
+	Pages      []Page
+	Signature  []byte // Sender's key and signature, sealed under the reality's key (optional)
 	Destroyed  bool
+	Read       bool // Read at least once; destroyed by reads rather than by policy
+
+	ViewPolicy ViewPolicy
+	views      int         // Successful reads so far, never reported
+	paging     *expiryItem // Open paged read, see ServePage
+	served     []bool      // Pages served since paging opened
+	scrub      bool        // Destroyed with random bytes: a slot of a deniable container
+}
+
+// Page is one fixed-size, separately encrypted slice of a paged reality.
+type Page struct {
+	Ciphertext []byte
+	Nonce      []byte
+}
+
+// destroy zeroizes the ciphertext in place before dropping it.
+func (r *MessageReality) destroy() {
+	if r == nil {
+		return
+	}
+	if r.scrub {
+		rand.Read(r.Ciphertext)
+	} else {
+		crypto.Zeroize(r.Ciphertext)
+	}
+	crypto.Zeroize(r.Nonce)
+	crypto.Zeroize(r.Signature)
+	for _, p := range r.Pages {
+		crypto.Zeroize(p.Ciphertext)
+		crypto.Zeroize(p.Nonce)
+	}
+	r.Ciphertext = nil
+	r.Nonce = nil
+	r.Signature = nil
+	r.Pages = nil
+	r.Destroyed = true
 }
 
 // GeoConstraint v2.5
@@ -20,38 +77,276 @@ type GeoConstraint struct {
 	RadiusKm  float64
 }
 
-// SecureEntry is the container for a dual-reality message.
-// It is indexed by the Receiver Token (RX).
+// Tripwire actions, fired when a honeypot slot is read or probed.
+const (
+	TripwireWipeNamespace = "wipe-namespace" // Wipe every slot under Namespace
+	TripwireLockdown      = "lockdown"       // Stop accepting sends
+	TripwireRevokeTx      = "revoke-tx"      // Revoke TxToken
+	TripwireAlert         = "alert"          // Raise an internal alert only
+)
+
+// Tripwire marks an entry as a canary. Its content is plausible and is
+// served like any other reality; touching the slot fires Action.
+type Tripwire struct {
+	Action    string
+	Namespace string // RX prefix for TripwireWipeNamespace
+	TxToken   string // Token for TripwireRevokeTx
+}
+
+// SecureEntry is the container for a multi-reality message.
+// It is indexed by the Receiver Token (RX). Realities[0] is the surface
+// reality (A); a classic dual-reality message holds exactly A and B.
 type SecureEntry struct {
-	RealityA   *MessageReality
-	RealityB   *MessageReality
+	Realities  []*MessageReality
 	Geo        GeoConstraint // v2.5 Geofencing
 	ExpiryTime time.Time
+	Schedule   *Schedule      // Read windows; nil means any time before ExpiryTime
+	Policy     *policy.Policy // Access policy judged on every read; nil allows all
+
+	// Duress is a copy of the surface reality sealed under the duress
+	// credential DuressRx, which reaches this entry like a second RX.
+	// Reading through it burns every reality at once.
+	Duress   *MessageReality
+	DuressRx string
+
+	Tripwire *Tripwire // Set on operator honeypot slots
+
+	// Recall is the sender's capability to destroy the entry before it is
+	// read; Receipt lets the sender poll its outcome. Save keeps only their
+	// digests and clears both fields.
+	Recall  string
+	Receipt string
+
+	Reply string // Reply capability handed to whoever reads the entry
+
+	// Threshold > 0 means Realities[1] is sealed under a key split k-of-n
+	// among receivers; it opens once Threshold shares are presented, each
+	// authenticated under ShareKey.
+	Threshold int
+	ShareKey  []byte
+
+	// Deniable entries keep every reality's ciphertext, nonce inline, in
+	// one fixed-size blob. A burned slot is overwritten with random bytes
+	// rather than zeros, so it never shows which slots held a reality.
+	Deniable  bool
+	container []byte
+
+	slots   []string           // Store keys that point at this entry
+	recall  *[sha256.Size]byte // Key in the store's recall index
+	receipt *receipt           // Outcome reported to the sender
+
+	shares     [][]byte    // Threshold release session
+	shareTimer clock.Timer // Wipes the session when it lapses
+	expiry     *expiryItem // Slot in the store's expiry queue
+	epoch      *epoch      // Key generation the realities are sealed under
+}
+
+// Slot is the RX the entry was saved under, which sender signatures bind.
+func (e *SecureEntry) Slot() string {
+	if len(e.slots) == 0 {
+		return ""
+	}
+	return e.slots[0]
+}
+
+func (e *SecureEntry) destroy() {
+	for _, r := range e.Realities {
+		r.destroy()
+	}
+	e.Duress.destroy()
+	e.Reply = ""
+	crypto.Zeroize(e.ShareKey)
+	e.endShareSession()
 }
 
 // MemoryStore holds all active messages in RAM.
 type MemoryStore struct {
 	data          map[string]*SecureEntry
+	recalls       map[[sha256.Size]byte][]*SecureEntry // By recall digest; fan-out copies share one
+	receipts      map[[sha256.Size]byte]*receipt       // By receipt digest
+	replies       map[[sha256.Size]byte]time.Time      // Unspent reply capabilities
+	groups        map[[sha256.Size]byte]*group         // By admin capability digest
+	groupPosters  map[[sha256.Size]byte]*group         // By post capability digest
+	groupMembers  map[[sha256.Size]byte]*group         // By member credential digest
+	groupKeys     int                                  // Live group keys, at most MaxGroupKeys
 	mu            sync.RWMutex
 	LastHeartbeat time.Time // v2.5 Dead Man Switch
-}
+	deadManAfter  time.Duration
+
+	clock        clock.Clock
+	expiries     expiryQueue // Entries ordered by ExpiryTime
+	expiryTimer  clock.Timer // Armed for the head of expiries
+	deadManTimer clock.Timer
+	closed       bool
 
-var GlobalStore *MemoryStore
+	current    *epoch            // Epoch new entries are sealed under
+	epochs     map[uint64]*epoch // All epochs with live keys
+	epochTimer clock.Timer
+}
 
-func InitStore() {
-	GlobalStore = &MemoryStore{
+// NewMemoryStore creates an empty store and arms its dead man and epoch
+// rotation timers on clk (the wall clock if nil). Entries are destroyed by
+// an expiry timer at their exact ExpiryTime. Call Close to disarm them all.
+func NewMemoryStore(clk clock.Clock) *MemoryStore {
+	if clk == nil {
+		clk = clock.Real{}
+	}
+	s := &MemoryStore{
 		data:          make(map[string]*SecureEntry),
-		LastHeartbeat: time.Now(),
+		recalls:       make(map[[sha256.Size]byte][]*SecureEntry),
+		receipts:      make(map[[sha256.Size]byte]*receipt),
+		replies:       make(map[[sha256.Size]byte]time.Time),
+		LastHeartbeat: clk.Now(),
+		deadManAfter:  DefaultDeadManTimeout,
+		clock:         clk,
+	}
+	s.mu.Lock()
+	s.destroyEpochs()
+	s.wipeGroups()
+	s.deadManTimer = clk.AfterFunc(deadManInterval, s.deadManCheck)
+	s.epochTimer = clk.AfterFunc(epochInterval, s.rotate)
+	s.mu.Unlock()
+	return s
+}
+
+// Close disarms all timers and wipes all entries and epoch keys.
+func (s *MemoryStore) Close() {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return
+	}
+	s.closed = true
+	if s.expiryTimer != nil {
+		s.expiryTimer.Stop()
+	}
+	s.deadManTimer.Stop()
+	s.epochTimer.Stop()
+	s.mu.Unlock()
+
+	s.Wipe()
+
+	s.mu.Lock()
+	for _, e := range s.epochs {
+		e.destroy()
 	}
-	// Start cleanup routines here if needed, or in main
-	go GlobalStore.cleanupLoop()
-	go GlobalStore.deadManLoop()
+	s.epochs = nil
+	s.mu.Unlock()
+}
+
+// Save seals entry under the current epoch key, stores it under rxToken
+// and entry.DuressRx (Last-Write-Wins), indexes its recall and receipt
+// capabilities and schedules its destruction at entry.ExpiryTime.
+func (s *MemoryStore) Save(rxToken string, entry *SecureEntry) error {
+	return s.SaveAll([]string{rxToken}, []*SecureEntry{entry})
 }
 
-func (s *MemoryStore) Save(rxToken string, entry *SecureEntry) {
+// SaveAll is Save for a fan-out: entries[i] is stored under rxTokens[i].
+// Either every entry is stored or none is, and a failed batch is destroyed.
+// Entries carrying the same recall or receipt capability share it: one
+// recall destroys them all, and one receipt reports them all.
+func (s *MemoryStore) SaveAll(rxTokens []string, entries []*SecureEntry) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
-	s.data[rxToken] = entry
+
+	destroyAll := func() {
+		for _, entry := range entries {
+			entry.destroy()
+		}
+	}
+	if len(rxTokens) != len(entries) || len(entries) == 0 {
+		destroyAll()
+		return errBatch
+	}
+	slots := make([][]string, len(entries))
+	seen := make(map[string]bool)
+	for i, entry := range entries {
+		slots[i] = []string{rxTokens[i]}
+		if entry.DuressRx != "" && entry.DuressRx != rxTokens[i] {
+			slots[i] = append(slots[i], entry.DuressRx)
+		}
+		for _, slot := range slots[i] {
+			if seen[slot] {
+				destroyAll()
+				return errBatch
+			}
+			seen[slot] = true
+		}
+	}
+	for _, entry := range entries {
+		if err := s.sealEntry(entry); err != nil {
+			destroyAll()
+			return err
+		}
+	}
+
+	receipts := make(map[[sha256.Size]byte]*receipt)
+	for i, entry := range entries {
+		for _, slot := range slots[i] {
+			if old, exists := s.data[slot]; exists {
+				s.unschedule(old)
+				s.drop(old, StateDestroyed)
+			}
+		}
+		entry.slots = slots[i]
+		for _, slot := range slots[i] {
+			s.data[slot] = entry
+		}
+		if entry.Recall != "" {
+			digest := sha256.Sum256([]byte(entry.Recall))
+			entry.recall = &digest
+			entry.Recall = ""
+			s.recalls[digest] = append(s.recalls[digest], entry)
+		}
+		if entry.Receipt != "" {
+			digest := sha256.Sum256([]byte(entry.Receipt))
+			r, exists := receipts[digest]
+			if !exists {
+				r = &receipt{digest: digest}
+				receipts[digest] = r
+				s.receipts[digest] = r
+			}
+			r.entries = append(r.entries, entry)
+			entry.receipt = r
+			entry.Receipt = ""
+		}
+		s.schedule(entry)
+	}
+	return nil
+}
+
+// drop unlinks every slot of entry and destroys it. Its receipt reports
+// the realities nobody read as unread.
+// Must be called with s.mu held.
+func (s *MemoryStore) drop(entry *SecureEntry, unread string) {
+	s.retire(entry, unread)
+	for _, slot := range entry.slots {
+		if s.data[slot] == entry {
+			delete(s.data, slot)
+		}
+	}
+	if entry.recall != nil {
+		copies := slices.DeleteFunc(s.recalls[*entry.recall], func(e *SecureEntry) bool { return e == entry })
+		if len(copies) == 0 {
+			delete(s.recalls, *entry.recall)
+		} else {
+			s.recalls[*entry.recall] = copies
+		}
+	}
+	entry.destroy()
+}
+
+// Recall destroys every entry recall was issued for, along with every
+// reality not yet read. Unknown, burned and expired capabilities are
+// silently ignored.
+func (s *MemoryStore) Recall(recall string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	copies := s.recalls[sha256.Sum256([]byte(recall))]
+	for _, entry := range slices.Clone(copies) {
+		s.unschedule(entry)
+		s.drop(entry, StateDestroyed)
+	}
 }
 
 func (s *MemoryStore) Get(rxToken string) (*SecureEntry, bool) {
@@ -61,13 +356,53 @@ func (s *MemoryStore) Get(rxToken string) (*SecureEntry, bool) {
 	if !exists {
 		return nil, false
 	}
-	// Lazy expiry check on read
-	if time.Now().After(entry.ExpiryTime) {
+	// The expiry timer may still be in flight; never hand out a stale entry.
+	now := s.clock.Now()
+	if now.After(entry.ExpiryTime) {
+		return nil, false
+	}
+	// Outside its read windows an entry looks exactly like a missing one.
+	if !entry.Schedule.Open(now) {
 		return nil, false
 	}
 	return entry, true
 }
 
+// Burn destroys realities after a read, zeroizing their ciphertexts (all
+// pages at once for paged realities). Burning several realities in one
+// call is atomic for anyone else going through the store lock.
+// The caller must hold the store lock.
+func (s *MemoryStore) Burn(realities ...*MessageReality) {
+	for _, r := range realities {
+		r.destroy()
+	}
+}
+
+// RegisterReply makes a reply capability spendable once until until.
+// Only its digest is kept, and the expiry timer forgets it after until.
+func (s *MemoryStore) RegisterReply(capability string, until time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	digest := sha256.Sum256([]byte(capability))
+	s.replies[digest] = until
+	heap.Push(&s.expiries, &expiryItem{reply: &digest, deadline: until})
+	s.armExpiry()
+}
+
+// SpendReply consumes a reply capability. It returns false for unknown,
+// spent, lapsed and wiped capabilities.
+func (s *MemoryStore) SpendReply(capability string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	digest := sha256.Sum256([]byte(capability))
+	deadline, exists := s.replies[digest]
+	if !exists {
+		return false
+	}
+	delete(s.replies, digest)
+	return !s.clock.Now().After(deadline)
+}
+
 func (s *MemoryStore) Mu() *sync.RWMutex {
 	return &s.mu
 }
@@ -76,7 +411,14 @@ func (s *MemoryStore) Mu() *sync.RWMutex {
 func (s *MemoryStore) Heartbeat() {
 	s.mu.Lock()
 	defer s.mu.Unlock()
-	s.LastHeartbeat = time.Now()
+	s.LastHeartbeat = s.clock.Now()
+}
+
+// SetDeadManTimeout changes how long the store survives without a heartbeat.
+func (s *MemoryStore) SetDeadManTimeout(d time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.deadManAfter = d
 }
 
 // Expose locking for API atomic destruction
@@ -88,41 +430,66 @@ func (s *MemoryStore) Unlock() {
 	s.mu.Unlock()
 }
 
-// Wipe destroys EVERYTHING (Factory Reset) using "Go's Map Clear"
+// WipeNamespace destroys every entry reachable through a slot starting
+// with prefix. An empty prefix is refused; use Wipe for a full reset.
+func (s *MemoryStore) WipeNamespace(prefix string) {
+	if prefix == "" {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for slot, entry := range s.data {
+		if strings.HasPrefix(slot, prefix) {
+			s.unschedule(entry)
+			s.drop(entry, StateDestroyed)
+		}
+	}
+}
+
+// Wipe destroys EVERYTHING (Factory Reset).
+// Destroying the epoch keys first makes every stored ciphertext
+// unrecoverable in constant time; the rest is hygiene.
 func (s *MemoryStore) Wipe() {
 	s.mu.Lock()
 	defer s.mu.Unlock()
-	// Reallocate map to clear old references instantly
+	s.destroyEpochs()
+
+	// Zeroize every ciphertext, then reallocate map to clear old references instantly
+	for _, entry := range s.data {
+		entry.expiry = nil
+		entry.destroy()
+	}
 	s.data = make(map[string]*SecureEntry)
-	// Theoretically we should zeroize old memory but GC handles map buckets.
-	// This is sufficient for "Panic Mode".
+	s.recalls = make(map[[sha256.Size]byte][]*SecureEntry)
+	s.receipts = make(map[[sha256.Size]byte]*receipt)
+	s.replies = make(map[[sha256.Size]byte]time.Time)
+	s.wipeGroups()
+	s.expiries = nil
+	if s.expiryTimer != nil {
+		s.expiryTimer.Stop()
+	}
 	// fmt.Println("🚨 PANIC WIPE TRIGGERED.")
 }
 
-func (s *MemoryStore) cleanupLoop() {
-	for {
-		time.Sleep(1 * time.Minute)
-		s.mu.Lock()
-		now := time.Now()
-		for rx, entry := range s.data {
-			if now.After(entry.ExpiryTime) {
-				delete(s.data, rx)
-			}
-		}
-		s.mu.Unlock()
+// deadManCheck wipes the store after the dead man timeout (24h by default)
+// without a heartbeat
+func (s *MemoryStore) deadManCheck() {
+	s.mu.RLock()
+	closed := s.closed
+	elapsed := clock.Since(s.clock, s.LastHeartbeat)
+	timeout := s.deadManAfter
+	s.mu.RUnlock()
+	if closed {
+		return
 	}
-}
 
-// deadManLoop checks only for 24h inactivity
-func (s *MemoryStore) deadManLoop() {
-	for {
-		time.Sleep(1 * time.Hour)
-		s.mu.RLock()
-		elapsed := time.Since(s.LastHeartbeat)
-		s.mu.RUnlock()
+	if elapsed > timeout {
+		s.Wipe()
+	}
 
-		if elapsed > 24*time.Hour {
-			s.Wipe()
-		}
+	s.mu.Lock()
+	if !s.closed {
+		s.deadManTimer.Reset(deadManInterval)
 	}
+	s.mu.Unlock()
 }
@@ -0,0 +1,62 @@
+package store
+
+import (
+	"time"
+
+	"zero-system/clock"
+	"zero-system/crypto"
+)
+
+// shareWindow is how long a threshold release session collects shares
+// after the first one arrives before it is wiped.
+const shareWindow = 10 * time.Minute
+
+// PresentShare adds share to the release session of entry's threshold
+// reality. Once entry.Threshold distinct shares are in, it ends the
+// session and hands them all to the caller, who must zeroize them.
+// Until then it returns nil. Shares never leave RAM and die with the
+// session, the entry or a wipe.
+// The caller must hold the store lock.
+func (s *MemoryStore) PresentShare(entry *SecureEntry, share []byte) [][]byte {
+	if entry.Threshold < 2 || len(share) < 2 {
+		return nil
+	}
+	for _, held := range entry.shares {
+		if held[0] == share[0] {
+			return nil // Same receiver twice counts once
+		}
+	}
+
+	entry.shares = append(entry.shares, append([]byte(nil), share...))
+	if len(entry.shares) == 1 {
+		var timer clock.Timer
+		timer = s.clock.AfterFunc(shareWindow, func() {
+			s.mu.Lock()
+			defer s.mu.Unlock()
+			if entry.shareTimer == timer { // Not a later session
+				entry.endShareSession()
+			}
+		})
+		entry.shareTimer = timer
+	}
+	if len(entry.shares) < entry.Threshold {
+		return nil
+	}
+
+	shares := entry.shares
+	entry.shares = nil
+	entry.endShareSession()
+	return shares
+}
+
+// endShareSession zeroizes collected shares and disarms the session timer.
+func (e *SecureEntry) endShareSession() {
+	for _, share := range e.shares {
+		crypto.Zeroize(share)
+	}
+	e.shares = nil
+	if e.shareTimer != nil {
+		e.shareTimer.Stop()
+		e.shareTimer = nil
+	}
+}
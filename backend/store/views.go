@@ -0,0 +1,61 @@
+package store
+
+import (
+	"container/heap"
+	"slices"
+	"time"
+)
+
+// ViewPolicy relaxes burn-on-read for one reality. The zero value is the
+// classic policy: the first read destroys it.
+type ViewPolicy struct {
+	Views  int           // Reads allowed before it burns (0 or 1: burn on first read)
+	Window time.Duration // Readable for this long after the first read, then burns
+}
+
+// View records a successful read of read, which is burned together with
+// burn under read's ViewPolicy: on the last allowed view, or by the expiry
+// timer once the view window closes, whichever comes first.
+// The caller must hold the store lock.
+func (s *MemoryStore) View(read *MessageReality, burn ...*MessageReality) {
+	read.Read = true
+	read.views++
+	policy := read.ViewPolicy
+
+	if policy.Window > 0 && read.views == 1 {
+		item := &expiryItem{burn: burn, deadline: s.clock.Now().Add(policy.Window)}
+		heap.Push(&s.expiries, item)
+		s.armExpiry()
+	}
+	if read.views >= policy.Views && (policy.Window == 0 || policy.Views > 0) {
+		s.Burn(burn...)
+	}
+}
+
+// ServePage records that page of the paged reality read was served. The
+// first page served opens a paged read, which counts as one View once
+// every page has been served, in any order, or, if the reader stops
+// early, once window has passed. It reports whether this page opened a
+// paged read.
+// The caller must hold the store lock.
+func (s *MemoryStore) ServePage(read *MessageReality, page int, window time.Duration, burn ...*MessageReality) bool {
+	opened := read.paging == nil
+	if opened {
+		read.paging = &expiryItem{view: read, burn: burn, deadline: s.clock.Now().Add(window)}
+		read.served = make([]bool, len(read.Pages))
+		heap.Push(&s.expiries, read.paging)
+		s.armExpiry()
+	}
+	read.served[page] = true
+	if slices.Contains(read.served, false) {
+		return opened
+	}
+
+	if read.paging.index >= 0 {
+		heap.Remove(&s.expiries, read.paging.index)
+		s.armExpiry()
+	}
+	read.paging, read.served = nil, nil
+	s.View(read, burn...)
+	return opened
+}
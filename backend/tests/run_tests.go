@@ -2,12 +2,28 @@ package main
 
 import (
 	"bytes"
+	"crypto/ed25519"
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"strings"
 	"sync"
 	"time"
+
+	"zero-system/api"
+	"zero-system/approval"
+	"zero-system/auth"
+	"zero-system/clock"
+	"zero-system/decoy"
+	"zero-system/guard"
+	"zero-system/pow"
+	"zero-system/ratelimit"
+	"zero-system/signing"
+	"zero-system/store"
 )
 
 const BASE_URL = "http://localhost:8080"
@@ -31,18 +47,26 @@ type ReadResponse struct {
 // --- Helpers ---
 
 func sendNote(tx, rx, a, b string) (*http.Response, error) {
+	return sendNoteTo(BASE_URL, tx, rx, a, b)
+}
+
+func sendNoteTo(baseURL, tx, rx, a, b string) (*http.Response, error) {
 	reqBody, _ := json.Marshal(SendRequest{
 		RealityA: a,
 		RealityB: b,
 		TxToken:  tx,
 		RxToken:  rx,
 	})
-	return http.Post(BASE_URL+"/api/send", "application/json", bytes.NewBuffer(reqBody))
+	return http.Post(baseURL+"/api/send", "application/json", bytes.NewBuffer(reqBody))
 }
 
 func readNote(rx string) (string, int, error) {
+	return readNoteFrom(BASE_URL, rx)
+}
+
+func readNoteFrom(baseURL, rx string) (string, int, error) {
 	reqBody, _ := json.Marshal(ReadRequest{RxToken: rx})
-	resp, err := http.Post(BASE_URL+"/api/read", "application/json", bytes.NewBuffer(reqBody))
+	resp, err := http.Post(baseURL+"/api/read", "application/json", bytes.NewBuffer(reqBody))
 	if err != nil {
 		return "", 0, err
 	}
@@ -63,6 +87,60 @@ func readNote(rx string) (string, int, error) {
 	return content, resp.StatusCode, nil
 }
 
+// startServer runs an in-process server for cfg behind a test listener.
+// stop shuts both down.
+func startServer(cfg api.Config) (ts *httptest.Server, stop func()) {
+	server := api.NewServer(cfg)
+	ts = httptest.NewServer(server.Handler())
+	return ts, func() {
+		ts.Close()
+		server.Close()
+	}
+}
+
+// postRaw posts req as JSON to path and returns the answer body as is.
+func postRaw(baseURL, path string, req any) []byte {
+	body, _ := json.Marshal(req)
+	resp, err := http.Post(baseURL+path, "application/json", bytes.NewBuffer(body))
+	if err != nil {
+		return nil
+	}
+	defer resp.Body.Close()
+	raw, _ := io.ReadAll(resp.Body)
+	return raw
+}
+
+// postJSON posts req as JSON to path and decodes the answer envelope,
+// which is the zero response if the request fails.
+func postJSON(baseURL, path string, req any) api.ReadResponse {
+	out, _ := postEnvelope(baseURL, path, req)
+	return out
+}
+
+// postEnvelope is postJSON that also reports the size of the answer, for
+// checks that envelopes do not give anything away.
+func postEnvelope(baseURL, path string, req any) (api.ReadResponse, int) {
+	raw := postRaw(baseURL, path, req)
+	var out api.ReadResponse
+	json.Unmarshal(raw, &out)
+	return out, len(raw)
+}
+
+// postForwarded is postJSON through a proxy forwarding for client.
+func postForwarded(baseURL, path, client string, req any) api.ReadResponse {
+	body, _ := json.Marshal(req)
+	r, _ := http.NewRequest("POST", baseURL+path, bytes.NewBuffer(body))
+	r.Header.Set("X-Forwarded-For", client)
+	resp, err := http.DefaultClient.Do(r)
+	if err != nil {
+		return api.ReadResponse{}
+	}
+	defer resp.Body.Close()
+	var out api.ReadResponse
+	json.NewDecoder(resp.Body).Decode(&out)
+	return out
+}
+
 func assert(condition bool, msg string) {
 	if !condition {
 		fmt.Printf("FAIL: %s\n", msg)
@@ -156,8 +234,10 @@ func TestAuthAndAbuse() {
 	// 1. Invalid TX Token
 	resp, _ := sendNote("INVALID-TX", "RX-TEST", "A", "B")
 	body, _ := io.ReadAll(resp.Body)
-	// Expecting "Note saved" (silent failure)
-	if string(body) == "Note saved" {
+	// Expecting "Note saved" (silent failure) in the fixed-size envelope
+	var ack api.ReadResponse
+	json.Unmarshal(body, &ack)
+	if ack.Content == "Note saved" && ack.Recall != "" && len(body) > api.RESPONSE_SIZE {
 		fmt.Println("  ✅ Invalid TX handled silently [Step 7]")
 	} else {
 		fmt.Printf("  ❌ Invalid TX leaked error? Got: %s\n", string(body))
@@ -207,7 +287,1181 @@ func TestConcurrency() {
 	}
 }
 
+// TestClockDrivenTiming runs an in-process server on a fake clock, so TTL,
+// dead man and refill behaviour are checked without waiting.
+func TestClockDrivenTiming() {
+	fmt.Println("\n[Category 6] Clock-Driven Timing Tests (In-Process)")
+
+	fake := clock.NewFake(time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC))
+	ts, stop := startServer(api.Config{Clock: fake, EntryTTL: 15 * time.Minute})
+	defer stop()
+
+	// 1. TTL: readable just before expiry, gone just after
+	sendNoteTo(ts.URL, "TX-clock", "RX-ttl-1", "Before", "B")
+	sendNoteTo(ts.URL, "TX-clock", "RX-ttl-2", "After", "B")
+	fake.Advance(15*time.Minute - time.Second)
+	if content, _, _ := readNoteFrom(ts.URL, "RX-ttl-1"); content == "Before" {
+		fmt.Println("  ✅ Entry readable before TTL")
+	} else {
+		fmt.Printf("  ❌ Entry missing before TTL. Got: '%s'\n", content)
+	}
+	fake.Advance(2 * time.Second)
+	if content, _, _ := readNoteFrom(ts.URL, "RX-ttl-2"); content == "No note available" {
+		fmt.Println("  ✅ Entry expired after TTL")
+	} else {
+		fmt.Printf("  ❌ Entry survived TTL. Got: '%s'\n", content)
+	}
+
+	// 2. Refill: send burst is 2, refill is 0.083 tokens/sec
+	for i := 0; i < 2; i++ {
+		sendNoteTo(ts.URL, "TX-clock", "RX-burst", "A", "B")
+	}
+	resp, _ := sendNoteTo(ts.URL, "TX-clock", "RX-burst", "A", "B")
+	blocked := resp != nil && resp.StatusCode == http.StatusTooManyRequests
+	fake.Advance(13 * time.Second)
+	resp, _ = sendNoteTo(ts.URL, "TX-clock", "RX-burst", "A", "B")
+	if blocked && resp != nil && resp.StatusCode == http.StatusOK {
+		fmt.Println("  ✅ Send limiter blocks burst and refills on the clock")
+	} else {
+		fmt.Println("  ❌ Send limiter refill mismatch")
+	}
+
+	// 3. Dead Man Switch: heartbeats keep entries alive, 24h of silence wipes them
+	tsLong, stopLong := startServer(api.Config{Clock: fake, EntryTTL: 72 * time.Hour})
+	defer stopLong()
+
+	sendNoteTo(tsLong.URL, "TX-clock", "RX-deadman", "Alive!", "Hidden")
+	fake.Advance(23 * time.Hour)
+	http.Post(tsLong.URL+"/api/heartbeat", "application/json", nil)
+	fake.Advance(23 * time.Hour)
+	contentA, _, _ := readNoteFrom(tsLong.URL, "RX-deadman")
+	fake.Advance(25 * time.Hour)
+	contentB, _, _ := readNoteFrom(tsLong.URL, "RX-deadman-B")
+	if contentA == "Alive!" && contentB == "No note available" {
+		fmt.Println("  ✅ Dead Man Switch honours heartbeat and wipes after 24h")
+	} else {
+		fmt.Printf("  ❌ Dead Man Switch mismatch. Got: '%s' / '%s'\n", contentA, contentB)
+	}
+
+	// 4. Closing a server leaves an injected store to its owner
+	memory := store.NewMemoryStore(fake)
+	defer memory.Close()
+	tsOwned, stopOwned := startServer(api.Config{Clock: fake, Store: memory})
+	sendNoteTo(tsOwned.URL, "TX-clock", "RX-owned", "Kept", "B")
+	stopOwned()
+	if _, ok := memory.Get("RX-owned"); ok {
+		fmt.Println("  ✅ Closing a server leaves an injected store intact")
+	} else {
+		fmt.Println("  ❌ Closing a server wiped the store it was given")
+	}
+}
+
+// TestPagedRead sends a reality larger than the 4KB envelope and fetches it
+// page by page from an in-process server.
+func TestPagedRead() {
+	fmt.Println("\n[Category 6] Paged Read Tests (In-Process)")
+
+	fake := clock.NewFake(time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC))
+	ts, stop := startServer(api.Config{
+		Clock:       fake,
+		SendLimiter: ratelimit.NewLimiter(100, 100, nil),
+		ReadLimiter: ratelimit.NewLimiter(100, 100, nil),
+	})
+	defer stop()
+
+	long := strings.Repeat("Situation report line. ", 400) // ~9KB
+	sendNoteTo(ts.URL, "TX-pages", "RX-pages", "Short decoy", long)
+
+	readPage := func(rx string, page int) (api.ReadResponse, int) {
+		return postEnvelope(ts.URL, "/api/read", api.ReadRequest{RxToken: rx, Page: page})
+	}
+
+	first, size := readPage("RX-pages-B", 0)
+	sizes := map[int]bool{size: true}
+	pages := []string{first.Content}
+	for i := 1; i < first.Pages; i++ {
+		page, size := readPage("RX-pages-B", i)
+		sizes[size] = true
+		pages = append(pages, page.Content)
+	}
+	text, err := api.AssemblePages(pages)
+	if err == nil && text == long && first.Pages == api.PAGE_COUNT && len(sizes) == 1 {
+		fmt.Printf("  ✅ Paged reality reassembled from %d identical responses\n", first.Pages)
+	} else {
+		fmt.Printf("  ❌ Paged read mismatch (pages=%d, sizes=%v, err=%v)\n", first.Pages, sizes, err)
+	}
+
+	if again, _ := readPage("RX-pages-B", 0); again.Content == "No note available" {
+		fmt.Println("  ✅ Paged reality burned once every page was served")
+	} else {
+		fmt.Println("  ❌ Paged reality survived its last page")
+	}
+
+	// Pages come in any order; asking for the last one first burns nothing
+	sendNoteTo(ts.URL, "TX-pages", "RX-backwards", "Short decoy", long)
+	backwards := make([]string, api.PAGE_COUNT)
+	for i := api.PAGE_COUNT - 1; i >= 0; i-- {
+		page, _ := readPage("RX-backwards-B", i)
+		backwards[i] = page.Content
+	}
+	text, err = api.AssemblePages(backwards)
+	if again, _ := readPage("RX-backwards-B", 0); err == nil && text == long && again.Content == "No note available" {
+		fmt.Println("  ✅ Out-of-order pages all arrive before the reality burns")
+	} else {
+		fmt.Printf("  ❌ Out-of-order paged read mismatch (err=%v, after='%s')\n", err, again.Content)
+	}
+
+	// A reader who stops before the last page gets PAGE_WINDOW, not forever
+	decoy, _ := readPage("RX-pages", 0)
+	readPage("RX-pages", 1)
+	fake.Advance(api.PAGE_WINDOW + time.Second)
+	if again, _ := readPage("RX-pages", 2); decoy.Pages == api.PAGE_COUNT && again.Content == "No note available" {
+		fmt.Println("  ✅ Decoy is paged too, and an unfinished paged read burns after its window")
+	} else {
+		fmt.Println("  ❌ Decoy served inline, or an unfinished paged read survived its window")
+	}
+	// Realities in the largest bucket must survive sealing for every copy:
+	// later recipients and the duress copy are sealed from the same text.
+	readAll := func(rx string) string {
+		first, _ := readPage(rx, 0)
+		pages := []string{first.Content}
+		for i := 1; i < first.Pages; i++ {
+			page, _ := readPage(rx, i)
+			pages = append(pages, page.Content)
+		}
+		text, _ := api.AssemblePages(pages)
+		return text
+	}
+	report := strings.Repeat("x", 5000)
+	for _, req := range []api.SendRequest{
+		{TxToken: "TX-pages", RxTokens: []string{"RX-fan-1", "RX-fan-2"}, RealityA: "Decoy", RealityB: report},
+		{TxToken: "TX-pages", RxToken: "RX-coerced", DuressToken: "RX-duress", RealityA: report, RealityB: "B"},
+	} {
+		postJSON(ts.URL, "/api/send", req)
+	}
+	if readAll("RX-fan-2-B") == report && readAll("RX-duress") == report {
+		fmt.Println("  ✅ Largest-bucket realities reach every copy intact")
+	} else {
+		fmt.Println("  ❌ A later copy of a largest-bucket reality was sealed empty")
+	}
+}
+
+// TestBruteForceEscalation guesses RX tokens against an in-process server
+// until it is challenged and then locked down.
+func TestBruteForceEscalation() {
+	fmt.Println("\n[Category 9] Brute-Force Escalation Tests (In-Process)")
+
+	proxy := []netip.Prefix{netip.MustParsePrefix("127.0.0.0/8")}
+	var alerts []string
+	ts, stop := startServer(api.Config{
+		Guard:          guard.Config{Client: guard.Thresholds{Challenge: 2.5, Lockdown: 5.5}},
+		TarpitDelay:    time.Millisecond,
+		ReadLimiter:    ratelimit.NewLimiter(100, 100, nil), // Guessing, not the limiter, is under test
+		ChallengeBits:  8,
+		Alerts:         func(a api.Alert) { alerts = append(alerts, a.Action) },
+		TrustedProxies: proxy,
+	})
+	defer stop()
+
+	// The guesser and a bystander both come through the trusted proxy
+	read := func(req api.ReadRequest) api.ReadResponse {
+		return postForwarded(ts.URL, "/api/read", "203.0.113.7", req)
+	}
+
+	sendNoteTo(ts.URL, "TX-guard", "RX-guard", "Alpha", "Bravo")
+	for i := 0; i < 3; i++ {
+		read(api.ReadRequest{RxToken: fmt.Sprintf("RX-guess-%d", i)})
+	}
+
+	challenged := read(api.ReadRequest{RxToken: "RX-guard"})
+	solved := read(api.ReadRequest{
+		RxToken:   "RX-guard",
+		Challenge: challenged.Challenge,
+		Solution:  pow.Solve(challenged.Challenge),
+	})
+	if challenged.Content == "No note available" && challenged.Challenge != "" && solved.Content == "Alpha" {
+		fmt.Println("  ✅ Challenged reader gets the generic failure until the puzzle is solved")
+	} else {
+		fmt.Printf("  ❌ Challenge mismatch. Got: '%s' / '%s'\n", challenged.Content, solved.Content)
+	}
+
+	for i := 3; i < 6; i++ {
+		c := read(api.ReadRequest{RxToken: "RX-guard"})
+		read(api.ReadRequest{RxToken: fmt.Sprintf("RX-guess-%d", i), Challenge: c.Challenge, Solution: pow.Solve(c.Challenge)})
+	}
+	postForwarded(ts.URL, "/api/send", "203.0.113.7", api.SendRequest{TxToken: "TX-guard", RxToken: "RX-after", RealityA: "Late", RealityB: "Note"})
+	postForwarded(ts.URL, "/api/send", "198.51.100.9", api.SendRequest{TxToken: "TX-guard", RxToken: "RX-other", RealityA: "Still", RealityB: "Open"})
+	late := postForwarded(ts.URL, "/api/read", "198.51.100.9", api.ReadRequest{RxToken: "RX-after"})
+	other := postForwarded(ts.URL, "/api/read", "198.51.100.9", api.ReadRequest{RxToken: "RX-other"})
+	fired := strings.Join(alerts, ",")
+	if late.Content == "No note available" && other.Content == "Still" &&
+		strings.Contains(fired, guard.StageLockdown.String()) && !strings.Contains(fired, api.LockdownRefuseSends) {
+		fmt.Println("  ✅ A client in lockdown loses its own sends, and only its own")
+	} else {
+		fmt.Printf("  ❌ Client lockdown mismatch. Got: '%s' / '%s', alerts %v\n", late.Content, other.Content, alerts)
+	}
+
+	// Without a trusted proxy, rotating X-Forwarded-For buys no fresh key
+	direct, stopDirect := startServer(api.Config{
+		Guard:         guard.Config{Client: guard.Thresholds{Challenge: 2.5, Lockdown: 100}},
+		ReadLimiter:   ratelimit.NewLimiter(100, 100, nil),
+		ChallengeBits: 8,
+		Alerts:        func(api.Alert) {},
+	})
+	defer stopDirect()
+	for i := 0; i < 3; i++ {
+		postForwarded(direct.URL, "/api/read", fmt.Sprintf("192.0.2.%d", i), api.ReadRequest{RxToken: "RX-guess"})
+	}
+	if postForwarded(direct.URL, "/api/read", "192.0.2.99", api.ReadRequest{RxToken: "RX-guess"}).Challenge != "" {
+		fmt.Println("  ✅ The guard keys on the peer, not on a forwarded header it chose")
+	} else {
+		fmt.Println("  ❌ A spoofed X-Forwarded-For reset the guard")
+	}
+
+	// Only the server as a whole reaching lockdown runs the global action
+	alerts = nil
+	global, stopGlobal := startServer(api.Config{
+		Guard: guard.Config{
+			Client: guard.Thresholds{Lockdown: 100},
+			Global: guard.Thresholds{Lockdown: 3.5},
+		},
+		ReadLimiter:    ratelimit.NewLimiter(100, 100, nil),
+		Alerts:         func(a api.Alert) { alerts = append(alerts, a.Action) },
+		TrustedProxies: proxy,
+	})
+	defer stopGlobal()
+	for i := 0; i < 4; i++ {
+		postForwarded(global.URL, "/api/read", fmt.Sprintf("198.18.0.%d", i), api.ReadRequest{RxToken: "RX-guess"})
+	}
+	postForwarded(global.URL, "/api/send", "198.51.100.9", api.SendRequest{TxToken: "TX-guard", RxToken: "RX-global", RealityA: "Late", RealityB: "Note"})
+	refused := postForwarded(global.URL, "/api/read", "198.51.100.9", api.ReadRequest{RxToken: "RX-global"})
+	if refused.Content == "No note available" && strings.Contains(strings.Join(alerts, ","), api.LockdownRefuseSends) {
+		fmt.Println("  ✅ Global lockdown stops accepting sends and alerts the operator")
+	} else {
+		fmt.Printf("  ❌ Global lockdown mismatch. Got: '%s', alerts %v\n", refused.Content, alerts)
+	}
+}
+
+// TestProofOfWorkAdmission runs an in-process server that admits sends and
+// reads by client puzzle instead of per-IP rate limits.
+func TestProofOfWorkAdmission() {
+	fmt.Println("\n[Category 9] Proof-of-Work Admission Tests (In-Process)")
+
+	ts, stop := startServer(api.Config{Admission: api.AdmitProofOfWork, PowBase: 8, PowMax: 10})
+	defer stop()
+
+	solved := func() (string, string) {
+		c := postJSON(ts.URL, "/api/challenge", nil).Challenge
+		return c, pow.Solve(c)
+	}
+
+	// More sends than the send limiter's burst: only the work counts.
+	postJSON(ts.URL, "/api/send", api.SendRequest{TxToken: "TX-work", RxToken: "RX-lazy", RealityA: "Lazy", RealityB: "Note"})
+	for i := 0; i < 3; i++ {
+		c, sol := solved()
+		postJSON(ts.URL, "/api/send", api.SendRequest{
+			TxToken: "TX-work", RxToken: fmt.Sprintf("RX-work-%d", i),
+			RealityA: "Worked", RealityB: "Note", Challenge: c, Solution: sol,
+		})
+	}
+
+	c, sol := solved()
+	lazy := postJSON(ts.URL, "/api/read", api.ReadRequest{RxToken: "RX-lazy", Challenge: c, Solution: sol})
+	unsolved := postJSON(ts.URL, "/api/read", api.ReadRequest{RxToken: "RX-work-2"})
+	c, sol = solved()
+	worked := postJSON(ts.URL, "/api/read", api.ReadRequest{RxToken: "RX-work-2", Challenge: c, Solution: sol})
+	replayed := postJSON(ts.URL, "/api/read", api.ReadRequest{RxToken: "RX-work-1", Challenge: c, Solution: sol})
+
+	if lazy.Content == "No note available" && worked.Content == "Worked" {
+		fmt.Println("  ✅ Only sends carrying a solved puzzle are admitted")
+	} else {
+		fmt.Printf("  ❌ Send admission mismatch. Got: '%s' / '%s'\n", lazy.Content, worked.Content)
+	}
+	if unsolved.Content == "No note available" && unsolved.Challenge != "" && replayed.Content == "No note available" {
+		fmt.Println("  ✅ Unsolved and replayed reads get the generic failure with a fresh puzzle")
+	} else {
+		fmt.Printf("  ❌ Read admission mismatch. Got: '%s' / '%s'\n", unsolved.Content, replayed.Content)
+	}
+}
+
+// TestBlindCredentials obtains an unlinkable send credential from an
+// in-process server and spends it twice.
+func TestBlindCredentials() {
+	fmt.Println("\n[Category 3] Blind Credential Tests (In-Process)")
+
+	// Roomy send limiter: the replay must be refused by the spend, not the rate
+	fake := clock.NewFake(time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC))
+	ts, stop := startServer(api.Config{
+		Clock:            fake,
+		SendLimiter:      ratelimit.NewLimiter(100, 100, nil),
+		BlindKeyLifetime: 24 * time.Hour,
+	})
+	defer stop()
+
+	issue := func() (credential, blinded, signed string, err error) {
+		var key api.IssuerKey
+		if resp, err := http.Get(ts.URL + "/api/issuer"); err == nil {
+			json.NewDecoder(resp.Body).Decode(&key)
+			resp.Body.Close()
+		}
+		pub, err := api.ParseIssuerKey(key)
+		if err != nil {
+			return "", "", "", err
+		}
+		request, raw, _ := auth.Blind(pub)
+		blinded = base64.RawURLEncoding.EncodeToString(raw)
+		issued := postJSON(ts.URL, "/api/issue", api.IssueRequest{TxToken: "TX-issuer", Blinded: blinded})
+		sig, _ := base64.RawURLEncoding.DecodeString(issued.Content)
+		credential, err = request.Finalize(pub, sig)
+		return credential, blinded, issued.Content, err
+	}
+	spend := func(credential, rx string) string {
+		sendNoteTo(ts.URL, credential, rx, "Anonymous", "Note")
+		content, _, _ := readNoteFrom(ts.URL, rx)
+		return content
+	}
+
+	credential, blinded, signed, err := issue()
+	if err != nil {
+		fmt.Printf("  ❌ Blind signature did not unblind: %v\n", err)
+		return
+	}
+	if strings.Contains(credential, blinded) || strings.Contains(credential, signed) {
+		fmt.Println("  ❌ Credential carries issuance data")
+	}
+	kept, _, _, _ := issue()
+	stale, _, _, _ := issue()
+
+	first := spend(credential, "RX-blind-1")
+	second := spend(credential, "RX-blind-2")
+	if first == "Anonymous" && second == "No note available" {
+		fmt.Println("  ✅ Blind credential sends once and is refused on double-spend")
+	} else {
+		fmt.Printf("  ❌ Blind credential mismatch. Got: '%s' / '%s'\n", first, second)
+	}
+
+	// The key rotates every lifetime; credentials outlive one rotation only
+	fake.Advance(25 * time.Hour)
+	fresh, _, _, err := issue()
+	spentKept := spend(kept, "RX-blind-3")
+	spentFresh := spend(fresh, "RX-blind-4")
+	fake.Advance(24 * time.Hour)
+	spentStale := spend(stale, "RX-blind-5")
+	if err == nil && spentKept == "Anonymous" && spentFresh == "Anonymous" && spentStale == "No note available" {
+		fmt.Println("  ✅ Credentials survive one key rotation and expire with the next")
+	} else {
+		fmt.Printf("  ❌ Key rotation mismatch. Got: '%s' / '%s' / '%s' (%v)\n", spentKept, spentFresh, spentStale, err)
+	}
+}
+
+// TestRecall sends notes to an in-process server and recalls them before
+// and after they are read.
+func TestRecall() {
+	fmt.Println("\n[Category 4] Sender Recall Tests (In-Process)")
+
+	ts, stop := startServer(api.Config{SendLimiter: ratelimit.NewLimiter(100, 100, nil)})
+	defer stop()
+
+	send := func(tx, rx string) (api.ReadResponse, int) {
+		resp, err := sendNoteTo(ts.URL, tx, rx, "Wrong", "Slot")
+		if err != nil {
+			return api.ReadResponse{}, 0
+		}
+		defer resp.Body.Close()
+		raw, _ := io.ReadAll(resp.Body)
+		var ack api.ReadResponse
+		json.Unmarshal(raw, &ack)
+		return ack, len(raw)
+	}
+	recall := func(capability string) []byte {
+		return postRaw(ts.URL, "/api/recall", api.RecallRequest{Recall: capability})
+	}
+
+	live, liveSize := send("TX-recall", "RX-recall-live")
+	read, _ := send("TX-recall", "RX-recall-read")
+	_, failSize := send("INVALID-TX", "RX-recall-none")
+	if liveSize != failSize {
+		fmt.Printf("  ❌ Send envelopes differ: %d vs %d bytes\n", liveSize, failSize)
+	}
+
+	if content, _, _ := readNoteFrom(ts.URL, "RX-recall-live-B"); content == "No note available" {
+		fmt.Println("  ❌ Recall capability consumed by reading")
+	}
+	if content, _, _ := readNoteFrom(ts.URL, live.Recall); content != "No note available" {
+		fmt.Println("  ❌ Recall capability opens the message")
+	}
+	readNoteFrom(ts.URL, "RX-recall-read")
+
+	first := recall(live.Recall)
+	afterBurn := recall(read.Recall)
+	unknown := recall("RC-unknown")
+	content, _, _ := readNoteFrom(ts.URL, "RX-recall-live")
+	if content == "No note available" && bytes.Equal(first, afterBurn) && bytes.Equal(first, unknown) {
+		fmt.Println("  ✅ Recall destroys unread realities and looks identical in every case")
+	} else {
+		fmt.Printf("  ❌ Recall mismatch. Got: '%s'\n", content)
+	}
+}
+
+// TestReceipts polls receipts on an in-process server driven by a fake
+// clock through read, recall, expiry and panic.
+func TestReceipts() {
+	fmt.Println("\n[Category 4] Read Receipt Tests (In-Process)")
+
+	fake := clock.NewFake(time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC))
+	ts, stop := startServer(api.Config{
+		Clock:       fake,
+		EntryTTL:    15 * time.Minute,
+		SendLimiter: ratelimit.NewLimiter(100, 100, fake),
+	})
+	defer stop()
+
+	states := func(receipt string) string {
+		resp := postJSON(ts.URL, "/api/receipt", api.ReceiptRequest{Receipt: receipt})
+		if resp.States == nil {
+			return resp.Content
+		}
+		return strings.Join(resp.States, ",")
+	}
+
+	readA := postJSON(ts.URL, "/api/send", api.SendRequest{TxToken: "TX-receipt", RxToken: "RX-receipt-a", RealityA: "A", RealityB: "B"})
+	readB := postJSON(ts.URL, "/api/send", api.SendRequest{TxToken: "TX-receipt", RxToken: "RX-receipt-b", RealityA: "A", RealityB: "B"})
+	recalled := postJSON(ts.URL, "/api/send", api.SendRequest{TxToken: "TX-receipt", RxToken: "RX-receipt-c", RealityA: "A", RealityB: "B"})
+
+	pending := states(readA.Receipt)
+	readNoteFrom(ts.URL, "RX-receipt-a")
+	readNoteFrom(ts.URL, "RX-receipt-b-B")
+	if pending == "pending,pending" && states(readA.Receipt) == "pending,read" && states(readB.Receipt) == "pending,read" {
+		fmt.Println("  ✅ Receipt reports a read without telling which reality")
+	} else {
+		fmt.Printf("  ❌ Read receipt mismatch. Got: '%s' / '%s'\n", pending, states(readA.Receipt))
+	}
+
+	postJSON(ts.URL, "/api/recall", api.RecallRequest{Recall: recalled.Recall})
+	if got := states(recalled.Receipt); got == "destroyed,destroyed" {
+		fmt.Println("  ✅ Receipt reports recalled realities as destroyed")
+	} else {
+		fmt.Printf("  ❌ Recall receipt mismatch. Got: '%s'\n", got)
+	}
+
+	fake.Advance(16 * time.Minute)
+	expired := states(readA.Receipt)
+	fake.Advance(time.Hour)
+	lapsed := states(readA.Receipt)
+	if expired == "expired,read" && lapsed == "No note available" {
+		fmt.Println("  ✅ Receipt reports expiry, then expires itself")
+	} else {
+		fmt.Printf("  ❌ Expiry receipt mismatch. Got: '%s' / '%s'\n", expired, lapsed)
+	}
+
+	wiped := postJSON(ts.URL, "/api/send", api.SendRequest{TxToken: "TX-receipt", RxToken: "RX-receipt-d", RealityA: "A", RealityB: "B"})
+	http.Post(ts.URL+"/api/panic", "application/json", nil)
+	if got := states(wiped.Receipt); got == "No note available" {
+		fmt.Println("  ✅ Receipt wiped with its entry")
+	} else {
+		fmt.Printf("  ❌ Receipt survived a wipe. Got: '%s'\n", got)
+	}
+}
+
+// TestReplyChain holds a three-message conversation on an in-process server
+// without exchanging any new credentials.
+func TestReplyChain() {
+	fmt.Println("\n[Category 4] Reply Chain Tests (In-Process)")
+
+	ts, stop := startServer(api.Config{SendLimiter: ratelimit.NewLimiter(100, 100, nil)})
+	defer stop()
+
+	// Alice -> Bob, asking for replies
+	sent := postJSON(ts.URL, "/api/send", api.SendRequest{TxToken: "TX-alice", RxToken: "RX-bob", RealityA: "Ping", RealityB: "Hidden", Reply: true})
+	first := postJSON(ts.URL, "/api/read", api.ReadRequest{RxToken: "RX-bob"})
+
+	// Bob -> Alice, with the capability from the read
+	postJSON(ts.URL, "/api/send", api.SendRequest{TxToken: first.Reply, RealityA: "Pong", RealityB: "Hidden"})
+	replay := postJSON(ts.URL, "/api/send", api.SendRequest{TxToken: first.Reply, RealityA: "Forged", RealityB: "Hidden"})
+	second := postJSON(ts.URL, "/api/read", api.ReadRequest{RxToken: api.ReplySlot(sent.Reply)})
+
+	// Alice -> Bob, one link further down the chain
+	postJSON(ts.URL, "/api/send", api.SendRequest{TxToken: second.Reply, RealityA: "Ping again", RealityB: "Hidden"})
+	third := postJSON(ts.URL, "/api/read", api.ReadRequest{RxToken: api.ReplySlot(api.NextReply(first.Reply))})
+
+	if first.Content == "Ping" && first.Reply == sent.Reply && second.Content == "Pong" && third.Content == "Ping again" {
+		fmt.Println("  ✅ Conversation continues through the reply chain")
+	} else {
+		fmt.Printf("  ❌ Reply chain mismatch. Got: '%s' / '%s' / '%s'\n", first.Content, second.Content, third.Content)
+	}
+	if replay.Content == "Note saved" && second.Content != "Forged" {
+		fmt.Println("  ✅ Reply capability is single-use and fails silently")
+	} else {
+		fmt.Println("  ❌ Reply capability replayed")
+	}
+}
+
+// TestThresholdRelease splits Reality B 2-of-3 on an in-process server and
+// releases it only once two receivers have presented their shares.
+func TestThresholdRelease() {
+	fmt.Println("\n[Category 4] Threshold Release Tests (In-Process)")
+
+	fake := clock.NewFake(time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC))
+	ts, stop := startServer(api.Config{Clock: fake, EntryTTL: time.Hour})
+	defer stop()
+
+	ack := postJSON(ts.URL, "/api/send", api.SendRequest{
+		TxToken: "TX-council", RxToken: "RX-council", RealityA: "Minutes", RealityB: "Launch codes",
+		Threshold: 2, Shares: 3,
+	})
+	if len(ack.Shares) != 3 {
+		fmt.Printf("  ❌ Expected 3 share credentials, got %d\n", len(ack.Shares))
+		return
+	}
+
+	direct, _, _ := readNoteFrom(ts.URL, "RX-council-B")
+	first, _, _ := readNoteFrom(ts.URL, ack.Shares[0])
+	again, _, _ := readNoteFrom(ts.URL, ack.Shares[0])
+	second, _, _ := readNoteFrom(ts.URL, ack.Shares[2])
+	if direct == "No note available" && first == "No note available" && again == "No note available" && second == "Launch codes" {
+		fmt.Println("  ✅ Reality B opens on the second distinct share only")
+	} else {
+		fmt.Printf("  ❌ Threshold mismatch. Got: '%s' / '%s' / '%s' / '%s'\n", direct, first, again, second)
+	}
+
+	ack = postJSON(ts.URL, "/api/send", api.SendRequest{
+		TxToken: "TX-council", RxToken: "RX-council-2", RealityA: "Minutes", RealityB: "Launch codes",
+		Threshold: 2, Shares: 3,
+	})
+	readNoteFrom(ts.URL, ack.Shares[0])
+	fake.Advance(11 * time.Minute)
+	late, _, _ := readNoteFrom(ts.URL, ack.Shares[1])
+	if late == "No note available" {
+		fmt.Println("  ✅ Lapsed share session is wiped")
+	} else {
+		fmt.Printf("  ❌ Share outlived its session. Got: '%s'\n", late)
+	}
+
+	// A holder who relabels its share as another's x is not counted, so it
+	// can neither poison the quorum nor crowd out the real share
+	ack = postJSON(ts.URL, "/api/send", api.SendRequest{
+		TxToken: "TX-council", RxToken: "RX-council-3", RealityA: "Minutes", RealityB: "Launch codes",
+		Threshold: 2, Shares: 3,
+	})
+	dot := strings.LastIndexByte(ack.Shares[0], '.')
+	own, _ := base64.RawURLEncoding.DecodeString(ack.Shares[0][dot+1:])
+	other, _ := base64.RawURLEncoding.DecodeString(ack.Shares[1][strings.LastIndexByte(ack.Shares[1], '.')+1:])
+	own[0] = other[0]
+	relabelled := ack.Shares[0][:dot+1] + base64.RawURLEncoding.EncodeToString(own)
+	forged, _, _ := readNoteFrom(ts.URL, relabelled)
+	readNoteFrom(ts.URL, ack.Shares[1])
+	opened, _, _ := readNoteFrom(ts.URL, ack.Shares[2])
+	if forged == "No note available" && opened == "Launch codes" {
+		fmt.Println("  ✅ Relabelled shares are ignored")
+	} else {
+		fmt.Printf("  ❌ Relabelled share counted. Got: '%s' / '%s'\n", forged, opened)
+	}
+}
+
+// TestTwoPersonRule gates panic and revocation behind 2-of-3 operator
+// approvals on an in-process server.
+func TestTwoPersonRule() {
+	fmt.Println("\n[Category 9] Two-Person Rule Tests (In-Process)")
+
+	var keys []ed25519.PublicKey
+	var privs []ed25519.PrivateKey
+	for i := 0; i < 3; i++ {
+		pub, priv, _ := ed25519.GenerateKey(nil)
+		keys, privs = append(keys, pub), append(privs, priv)
+	}
+	policy := approval.Policy{Keys: keys, K: 2, Window: 5 * time.Minute}
+	ts, stop := startServer(api.Config{
+		OperatorToken: "OP-two",
+		Approvals:     map[string]approval.Policy{api.ActionPanic: policy, api.ActionRevoke: policy},
+		SendLimiter:   ratelimit.NewLimiter(100, 100, nil),
+		ReadLimiter:   ratelimit.NewLimiter(100, 100, nil),
+	})
+	defer stop()
+
+	post := func(path string, req any) string {
+		return string(postRaw(ts.URL, path, req))
+	}
+	now := time.Now()
+
+	// Revocation: one approval is not enough, the second revokes
+	revoke := func(i int) {
+		post("/api/revoke", api.RevokeRequest{
+			TxToken:  "TX-rogue",
+			Approval: api.SignApproval(privs[i], api.ActionRevoke, []byte("TX-rogue"), now),
+		})
+	}
+	revoke(0)
+	sendNoteTo(ts.URL, "TX-rogue", "RX-rogue-1", "Still", "Allowed")
+	revoke(0) // Same operator twice counts once
+	sendNoteTo(ts.URL, "TX-rogue", "RX-rogue-2", "Still", "Allowed")
+	revoke(2)
+	sendNoteTo(ts.URL, "TX-rogue", "RX-rogue-3", "Now", "Refused")
+	first, _, _ := readNoteFrom(ts.URL, "RX-rogue-1")
+	second, _, _ := readNoteFrom(ts.URL, "RX-rogue-2")
+	third, _, _ := readNoteFrom(ts.URL, "RX-rogue-3")
+	if first == "Still" && second == "Still" && third == "No note available" {
+		fmt.Println("  ✅ Revocation waits for two distinct operators")
+	} else {
+		fmt.Printf("  ❌ Revocation mismatch. Got: '%s' / '%s' / '%s'\n", first, second, third)
+	}
+
+	// A tripwire that revokes needs its own quorum, which never counts
+	// towards revoking directly
+	plant := func(rx string, approvals ...int) {
+		req := api.TripwireRequest{
+			OperatorToken: "OP-two", RxToken: rx, Realities: []string{"Canary"},
+			Action: store.TripwireRevokeTx, TxToken: "TX-victim",
+		}
+		post("/api/tripwire", req)
+		action, payload, _ := api.TripwireApproval(req)
+		for _, i := range approvals {
+			req.Approval = api.SignApproval(privs[i], action, payload, now)
+			post("/api/tripwire", req)
+		}
+	}
+	plant("RX-lone-wire", 0)
+	post("/api/revoke", api.RevokeRequest{
+		TxToken:  "TX-victim",
+		Approval: api.SignApproval(privs[1], api.ActionRevoke, []byte("TX-victim"), now),
+	})
+	readNoteFrom(ts.URL, "RX-lone-wire")
+	sendNoteTo(ts.URL, "TX-victim", "RX-victim-1", "Still", "Allowed")
+	plant("RX-quorum-wire", 0, 1)
+	readNoteFrom(ts.URL, "RX-quorum-wire")
+	sendNoteTo(ts.URL, "TX-victim", "RX-victim-2", "Now", "Refused")
+	lone, _, _ := readNoteFrom(ts.URL, "RX-victim-1")
+	quorum, _, _ := readNoteFrom(ts.URL, "RX-victim-2")
+	if lone == "Still" && quorum == "No note available" {
+		fmt.Println("  ✅ Gated tripwires need a quorum of their own")
+	} else {
+		fmt.Printf("  ❌ Tripwire approval mismatch. Got: '%s' / '%s'\n", lone, quorum)
+	}
+
+	// Panic: unsigned and forged requests are held, the quorum wipes
+	sendNoteTo(ts.URL, "TX-ops", "RX-ops", "Survivor", "B")
+	unsigned := post("/api/panic", nil)
+	forged := api.SignApproval(privs[1], api.ActionPanic, nil, now)
+	forged.Key = api.SignApproval(privs[0], api.ActionPanic, nil, now).Key
+	bad := post("/api/panic", api.PanicRequest{Approval: forged})
+	one := post("/api/panic", api.PanicRequest{Approval: api.SignApproval(privs[1], api.ActionPanic, nil, now)})
+	survivor, _, _ := readNoteFrom(ts.URL, "RX-ops-B")
+	two := post("/api/panic", api.PanicRequest{Approval: api.SignApproval(privs[2], api.ActionPanic, nil, now)})
+	if unsigned == "PENDING" && bad == "PENDING" && one == "PENDING" && survivor == "B" && two == "WIPED" {
+		fmt.Println("  ✅ Panic wipe needs two signed approvals")
+	} else {
+		fmt.Printf("  ❌ Panic approval mismatch. Got: %s / %s / %s / %s\n", unsigned, bad, one, two)
+	}
+}
+
+// TestReadWindows restricts reads to a daily check time in another time
+// zone on an in-process server driven by a fake clock.
+func TestReadWindows() {
+	fmt.Println("\n[Category 6] Read Window Tests (In-Process)")
+
+	fake := clock.NewFake(time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC))
+	ts, stop := startServer(api.Config{Clock: fake, EntryTTL: 48 * time.Hour})
+	defer stop()
+
+	send := func(rx string, schedule *api.ScheduleRequest) {
+		postJSON(ts.URL, "/api/send", api.SendRequest{TxToken: "TX-window", RxToken: rx, RealityA: "On time", RealityB: "B", Schedule: schedule})
+	}
+
+	// 08:00-08:15 in Tokyo is 23:00-23:15 UTC
+	send("RX-daily", &api.ScheduleRequest{TimeZone: "Asia/Tokyo", Daily: []api.DailyWindowSpec{{Start: "08:00", End: "08:15"}}})
+	send("RX-once", &api.ScheduleRequest{Windows: []api.IntervalWindowSpec{{From: "2025-01-01T12:00:00Z", To: "2025-01-01T12:30:00Z"}}})
+
+	early, _, _ := readNoteFrom(ts.URL, "RX-daily")
+	fake.Set(time.Date(2025, 1, 1, 12, 10, 0, 0, time.UTC))
+	once, _, _ := readNoteFrom(ts.URL, "RX-once")
+	fake.Set(time.Date(2025, 1, 1, 23, 5, 0, 0, time.UTC))
+	onTime, _, _ := readNoteFrom(ts.URL, "RX-daily")
+	if early == "No note available" && once == "On time" && onTime == "On time" {
+		fmt.Println("  ✅ Reads outside a window fail without burning the entry")
+	} else {
+		fmt.Printf("  ❌ Read window mismatch. Got: '%s' / '%s' / '%s'\n", early, once, onTime)
+	}
+
+	// Berlin springs forward on 2025-03-30, so 08:05 local is 06:05 UTC.
+	// A fresh server keeps the dead man switch out of the jump.
+	spring := clock.NewFake(time.Date(2025, 3, 30, 0, 0, 0, 0, time.UTC))
+	dst, stopDST := startServer(api.Config{Clock: spring, EntryTTL: 12 * time.Hour})
+	defer stopDST()
+	berlin := &api.ScheduleRequest{TimeZone: "Europe/Berlin", Daily: []api.DailyWindowSpec{{Start: "08:00", End: "08:15"}}}
+	for _, rx := range []string{"RX-dst-open", "RX-dst-closed"} {
+		postJSON(dst.URL, "/api/send", api.SendRequest{TxToken: "TX-window", RxToken: rx, RealityA: "On time", RealityB: "B", Schedule: berlin})
+	}
+	spring.Set(time.Date(2025, 3, 30, 6, 5, 0, 0, time.UTC))
+	open, _, _ := readNoteFrom(dst.URL, "RX-dst-open")
+	spring.Set(time.Date(2025, 3, 30, 7, 5, 0, 0, time.UTC))
+	closed, _, _ := readNoteFrom(dst.URL, "RX-dst-closed")
+	if open == "On time" && closed == "No note available" {
+		fmt.Println("  ✅ Daily windows follow the wall clock across a DST change")
+	} else {
+		fmt.Printf("  ❌ DST window mismatch. Got: '%s' / '%s'\n", open, closed)
+	}
+}
+
+// TestViewPolicies sends realities that survive several reads or linger
+// for a while after the first one, on a fake clock.
+func TestViewPolicies() {
+	fmt.Println("\n[Category 6] View Policy Tests (In-Process)")
+
+	fake := clock.NewFake(time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC))
+	ts, stop := startServer(api.Config{Clock: fake, ReadLimiter: ratelimit.NewLimiter(100, 100, nil)})
+	defer stop()
+
+	send := func(rx string, views ...api.ViewPolicySpec) {
+		postJSON(ts.URL, "/api/send", api.SendRequest{TxToken: "TX-views", RxToken: rx, RealityA: "Again", RealityB: "B", Views: views})
+	}
+	read := func(rx string) (string, int) {
+		out, size := postEnvelope(ts.URL, "/api/read", api.ReadRequest{RxToken: rx})
+		return out.Content, size
+	}
+
+	send("RX-thrice", api.ViewPolicySpec{Views: 3})
+	var got []string
+	sizes := map[int]bool{}
+	for i := 0; i < 4; i++ {
+		content, size := read("RX-thrice")
+		got = append(got, content)
+		sizes[size] = true
+	}
+	if strings.Join(got, ",") == "Again,Again,Again,No note available" && len(sizes) == 1 {
+		fmt.Println("  ✅ views: 3 allows exactly three reads, in identical envelopes")
+	} else {
+		fmt.Printf("  ❌ Multi-view mismatch. Got: %v, %d sizes\n", got, len(sizes))
+	}
+
+	send("RX-linger", api.ViewPolicySpec{WindowSeconds: 60})
+	first, _ := read("RX-linger")
+	fake.Advance(30 * time.Second)
+	second, _ := read("RX-linger")
+	fake.Advance(31 * time.Second) // The window closes with nobody reading
+	late, _ := read("RX-linger")
+	if first == "Again" && second == "Again" && late == "No note available" {
+		fmt.Println("  ✅ A view window burns at its deadline without another read")
+	} else {
+		fmt.Printf("  ❌ View window mismatch. Got: '%s' / '%s' / '%s'\n", first, second, late)
+	}
+
+	send("RX-default")
+	once, _ := read("RX-default")
+	twice, _ := read("RX-default")
+	if once == "Again" && twice == "No note available" {
+		fmt.Println("  ✅ Realities without a view policy still burn on first read")
+	} else {
+		fmt.Printf("  ❌ Default burn mismatch. Got: '%s' / '%s'\n", once, twice)
+	}
+}
+
+// TestFanOut sends one note to several receivers of an in-process server
+// and checks that every copy lives and dies on its own.
+func TestFanOut() {
+	fmt.Println("\n[Category 4] Fan-Out Send Tests (In-Process)")
+
+	ts, stop := startServer(api.Config{
+		SendLimiter: ratelimit.NewLimiter(100, 100, nil),
+		ReadLimiter: ratelimit.NewLimiter(100, 100, nil),
+	})
+	defer stop()
+
+	read := func(rx string) string {
+		out, _ := postEnvelope(ts.URL, "/api/read", api.ReadRequest{RxToken: rx})
+		return out.Content
+	}
+
+	team := []string{"RX-team-1", "RX-team-2", "RX-team-3", "RX-team-4", "RX-team-5"}
+	ack, teamSize := postEnvelope(ts.URL, "/api/send", api.SendRequest{TxToken: "TX-fan", RxTokens: team, RealityA: "Briefing", RealityB: "B"})
+	_, soloSize := postEnvelope(ts.URL, "/api/send", api.SendRequest{TxToken: "TX-fan", RxToken: "RX-solo", RealityA: "Briefing", RealityB: "B"})
+
+	first := []string{read("RX-team-1"), read("RX-team-1"), read("RX-team-2-B")}
+	states, _ := postEnvelope(ts.URL, "/api/receipt", api.ReceiptRequest{Receipt: ack.Receipt})
+	if strings.Join(first, ",") == "Briefing,No note available,B" && teamSize == soloSize && len(states.States) == 10 {
+		fmt.Println("  ✅ Every receiver holds an independent copy; the ack size ignores the team size")
+	} else {
+		fmt.Printf("  ❌ Fan-out mismatch. Got: %v, sizes %d/%d, %d states\n", first, teamSize, soloSize, len(states.States))
+	}
+
+	postEnvelope(ts.URL, "/api/recall", api.RecallRequest{Recall: ack.Recall})
+	recalled := read("RX-team-3") + "," + read("RX-team-5")
+	if recalled == "No note available,No note available" {
+		fmt.Println("  ✅ One recall destroys every remaining copy")
+	} else {
+		fmt.Printf("  ❌ Fan-out recall mismatch. Got: %s\n", recalled)
+	}
+
+	postEnvelope(ts.URL, "/api/send", api.SendRequest{TxToken: "TX-fan", RxTokens: []string{"RX-all-1", "not-an-rx", "RX-all-2"}, RealityA: "All", RealityB: "B"})
+	postEnvelope(ts.URL, "/api/send", api.SendRequest{TxToken: "TX-fan", RxTokens: []string{"RX-dup", "RX-dup"}, RealityA: "All", RealityB: "B"})
+	partial := read("RX-all-1") + "," + read("RX-dup")
+	if partial == "No note available,No note available" {
+		fmt.Println("  ✅ A fan-out with one bad slot stores nothing")
+	} else {
+		fmt.Printf("  ❌ Fan-out atomicity mismatch. Got: %s\n", partial)
+	}
+
+	// A slot ending in a reality suffix could never be read, so none is stored
+	suffixed := postJSON(ts.URL, "/api/send", api.SendRequest{TxToken: "TX-fan", RxToken: "RX-legacy-C", Realities: []string{"A", "B", "C"}})
+	postJSON(ts.URL, "/api/send", api.SendRequest{TxToken: "TX-fan", RxToken: "RX-plain", Realities: []string{"A", "B", "C"}})
+	refused, _ := postEnvelope(ts.URL, "/api/receipt", api.ReceiptRequest{Receipt: suffixed.Receipt})
+	if len(refused.States) == 0 && read("RX-plain-C") == "C" {
+		fmt.Println("  ✅ Slots ending in a reality suffix are refused at send time")
+	} else {
+		fmt.Printf("  ❌ Suffixed slot mismatch. Got %d states\n", len(refused.States))
+	}
+}
+
+// TestGroupChannels creates a group on an in-process server, posts to it,
+// rotates its membership and wipes it.
+func TestGroupChannels() {
+	fmt.Println("\n[Category 4] Group Channel Tests (In-Process)")
+
+	ts, stop := startServer(api.Config{
+		OperatorToken: "OP-groups",
+		SendLimiter:   ratelimit.NewLimiter(100, 100, nil),
+		ReadLimiter:   ratelimit.NewLimiter(100, 100, nil),
+	})
+	defer stop()
+
+	send := func(tx, text string) {
+		postJSON(ts.URL, "/api/send", api.SendRequest{TxToken: tx, RealityA: text})
+	}
+	read := func(member string) string {
+		return postJSON(ts.URL, "/api/read", api.ReadRequest{RxToken: member}).Content
+	}
+
+	// Only a sender with a valid, unrevoked TX may create a group
+	postJSON(ts.URL, "/api/revoke", api.RevokeRequest{OperatorToken: "OP-groups", TxToken: "TX-group-revoked"})
+	for _, tx := range []string{"", "TX-group-revoked"} {
+		stray := postJSON(ts.URL, "/api/group", api.GroupRequest{TxToken: tx, Add: 1})
+		send(stray.Post, "Unauthorised")
+		if read(stray.Members[0]) != "No note available" {
+			fmt.Printf("  ❌ Group created with TX %q\n", tx)
+			return
+		}
+	}
+	fmt.Println("  ✅ Groups need a valid, unrevoked TX")
+
+	group := postJSON(ts.URL, "/api/group", api.GroupRequest{TxToken: "TX-group", Add: 3})
+	m := group.Members
+	if len(m) != 3 {
+		fmt.Printf("  ❌ Group creation returned %d credentials\n", len(m))
+		return
+	}
+	send(group.Post, "First")
+	send(group.Post, "Second")
+	got := []string{read(m[0]), read(m[0]), read(m[0]), read(m[1]), read(m[2])}
+	if strings.Join(got, ",") == "First,Second,No note available,First,First" {
+		fmt.Println("  ✅ Every member reads every post once, oldest first")
+	} else {
+		fmt.Printf("  ❌ Group read mismatch. Got: %v\n", got)
+	}
+
+	rotated := postJSON(ts.URL, "/api/group", api.GroupRequest{TxToken: "TX-group", Admin: group.Group, Add: 1, Remove: []string{m[2]}})
+	send(group.Post, "Stale key")
+	send(rotated.Post, "Third")
+	late := []string{read(m[1]), read(m[1]), read(m[2]), read(rotated.Members[0]), read(m[0])}
+	if strings.Join(late, ",") == "Second,Third,No note available,Third,Third" {
+		fmt.Println("  ✅ Rotation locks out removed members and the old post capability")
+	} else {
+		fmt.Printf("  ❌ Group rotation mismatch. Got: %v\n", late)
+	}
+
+	send(rotated.Post, "Before panic")
+	postJSON(ts.URL, "/api/panic", struct{}{})
+	send(rotated.Post, "After panic")
+	if read(m[0]) == "No note available" && read(rotated.Members[0]) == "No note available" {
+		fmt.Println("  ✅ A panic wipe destroys groups and their posts")
+	} else {
+		fmt.Println("  ❌ Group survived a panic wipe")
+	}
+
+	// Idle groups expire and give their keys back to the store-wide cap
+	fake := clock.NewFake(time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC))
+	idle, stopIdle := startServer(api.Config{
+		Clock:       fake,
+		SendLimiter: ratelimit.NewLimiter(1000, 1000, nil),
+		ReadLimiter: ratelimit.NewLimiter(100, 100, nil),
+	})
+	defer stopIdle()
+	works := func() bool {
+		g := postJSON(idle.URL, "/api/group", api.GroupRequest{TxToken: "TX-group", Add: 1})
+		postJSON(idle.URL, "/api/send", api.SendRequest{TxToken: g.Post, RealityA: "Fresh"})
+		return postJSON(idle.URL, "/api/read", api.ReadRequest{RxToken: g.Members[0]}).Content == "Fresh"
+	}
+	for i := 0; i < store.MaxGroupKeys; i++ {
+		postJSON(idle.URL, "/api/group", api.GroupRequest{TxToken: "TX-group", Add: 1})
+	}
+	full := works()
+	fake.Advance(store.GroupTTL)
+	if !full && works() {
+		fmt.Println("  ✅ Idle groups expire and free their keys")
+	} else {
+		fmt.Printf("  ❌ Group expiry mismatch. Full store accepted a group: %v\n", full)
+	}
+}
+
+// TestSenderSignatures sends server-signed and client-signed notes to an
+// in-process server and checks what their readers learn.
+func TestSenderSignatures() {
+	fmt.Println("\n[Category 3] Sender Signature Tests (In-Process)")
+
+	fake := clock.NewFake(time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC))
+	ts, stop := startServer(api.Config{
+		Clock:       fake,
+		SendLimiter: ratelimit.NewLimiter(1000, 1000, nil),
+		ReadLimiter: ratelimit.NewLimiter(100, 100, nil),
+	})
+	defer stop()
+
+	read := func(rx string) api.ReadResponse {
+		return postJSON(ts.URL, "/api/read", api.ReadRequest{RxToken: rx})
+	}
+
+	registered := postJSON(ts.URL, "/api/signer", api.SignerRequest{TxToken: "TX-signer"})
+	postJSON(ts.URL, "/api/send", api.SendRequest{TxToken: "TX-signer", RxToken: "RX-signed", RealityA: "Decoy", RealityB: "Truth", Sign: true})
+	a, b := read("RX-signed-A"), read("RX-signed-B")
+	if a.Verified && b.Verified && a.Signer == registered.Signer && b.Signer == registered.Signer && a.Content == "Decoy" {
+		fmt.Println("  ✅ Server-held key signs both realities alike")
+	} else {
+		fmt.Printf("  ❌ Server signature mismatch. Got: %+v / %+v\n", a.Verified, b.Verified)
+	}
+
+	pub, priv, _ := ed25519.GenerateKey(nil)
+	expiry := fake.Now().Add(10 * time.Minute)
+	sigs := []string{
+		base64.StdEncoding.EncodeToString(signing.Sign(priv, "RX-client", expiry, "Decoy")),
+		base64.StdEncoding.EncodeToString(signing.Sign(priv, "RX-client", expiry, "Truth")),
+	}
+	signed := api.SendRequest{
+		TxToken: "TX-client", RxToken: "RX-client", RealityA: "Decoy", RealityB: "Truth",
+		SignerKey: base64.StdEncoding.EncodeToString(pub), Signatures: sigs, Expiry: expiry.Unix(),
+	}
+	postJSON(ts.URL, "/api/send", signed)
+	forged := signed
+	forged.RxToken, forged.RealityB = "RX-forged", "Tampered"
+	postJSON(ts.URL, "/api/send", forged)
+	postJSON(ts.URL, "/api/send", api.SendRequest{TxToken: "TX-client", RxToken: "RX-plain", RealityA: "Plain", RealityB: "B"})
+
+	client := read("RX-client-B")
+	plain := read("RX-plain")
+	if client.Verified && client.Signer == signing.Fingerprint(pub) && client.Content == "Truth" &&
+		read("RX-forged").Content == "No note available" && !plain.Verified && plain.Signer == "" {
+		fmt.Println("  ✅ Client signatures are checked on send and reported on read")
+	} else {
+		fmt.Printf("  ❌ Client signature mismatch. Got: %+v / '%s'\n", client.Verified, plain.Signer)
+	}
+
+	// A full registry hands out throwaway keys until idle ones expire
+	for i := 0; i < signing.MaxSigners; i++ {
+		postJSON(ts.URL, "/api/signer", api.SignerRequest{TxToken: fmt.Sprintf("TX-signer-%d", i)})
+	}
+	stable := func() bool {
+		first := postJSON(ts.URL, "/api/signer", api.SignerRequest{TxToken: "TX-signer-late"})
+		return postJSON(ts.URL, "/api/signer", api.SignerRequest{TxToken: "TX-signer-late"}).Signer == first.Signer
+	}
+	full := stable()
+	fake.Advance(signing.SignerTTL + time.Hour)
+	if !full && stable() {
+		fmt.Println("  ✅ Idle signer keys expire and free the registry")
+	} else {
+		fmt.Printf("  ❌ Signer expiry mismatch. Full registry kept a key: %v\n", full)
+	}
+}
+
+// TestDeniableContainers sends deniable notes with and without a hidden
+// reality and compares what the store holds for them.
+func TestDeniableContainers() {
+	fmt.Println("\n[Category 4] Deniable Container Tests (In-Process)")
+
+	memory := store.NewMemoryStore(nil)
+	defer memory.Close()
+	ts, stop := startServer(api.Config{
+		Store:       memory,
+		SendLimiter: ratelimit.NewLimiter(100, 100, nil),
+		ReadLimiter: ratelimit.NewLimiter(100, 100, nil),
+	})
+	defer stop()
+
+	send := func(rx, a, b string) {
+		postJSON(ts.URL, "/api/send", api.SendRequest{TxToken: "TX-deny", RxToken: rx, RealityA: a, RealityB: b, Deniable: true})
+	}
+	shape := func(rx string) []int {
+		entry, ok := memory.Get(rx)
+		if !ok {
+			return nil
+		}
+		var sizes []int
+		for _, r := range entry.Realities {
+			sizes = append(sizes, len(r.Ciphertext), len(r.Nonce))
+		}
+		return sizes
+	}
+
+	send("RX-hidden", "Groceries", "The real plan")
+	send("RX-surface", "Groceries", "")
+	hidden, surface := fmt.Sprint(shape("RX-hidden")), fmt.Sprint(shape("RX-surface"))
+	if hidden == surface && len(shape("RX-hidden")) == 2*api.CONTAINER_SLOTS {
+		fmt.Println("  ✅ Entries with and without a hidden reality look identical in the store")
+	} else {
+		fmt.Printf("  ❌ Container shape mismatch. Got: %s / %s\n", hidden, surface)
+	}
+
+	read := func(rx string) string {
+		content, _, _ := readNoteFrom(ts.URL, rx)
+		return content
+	}
+	got := []string{read("RX-hidden-B"), read("RX-hidden-A"), read("RX-surface-B"), read("RX-surface-A"), read("RX-hidden-B")}
+	if strings.Join(got, ",") == "The real plan,Groceries,No note available,Groceries,No note available" {
+		fmt.Println("  ✅ Each key opens its own slot; filler opens for nobody")
+	} else {
+		fmt.Printf("  ❌ Container read mismatch. Got: %v\n", got)
+	}
+
+	// A hidden text in the 4096 bucket fills its slot exactly; every copy
+	// of a fan-out must still carry it.
+	plan := strings.Repeat("p", 2000)
+	postJSON(ts.URL, "/api/send", api.SendRequest{TxToken: "TX-deny", RxTokens: []string{"RX-deny-1", "RX-deny-2"}, RealityA: "Groceries", RealityB: plan, Deniable: true})
+	if read("RX-deny-1-B") == plan && read("RX-deny-2-B") == plan {
+		fmt.Println("  ✅ Every copy of a deniable fan-out holds the hidden text")
+	} else {
+		fmt.Println("  ❌ A later deniable copy was sealed empty")
+	}
+}
+
+// TestDecoyGeneration checks the decoy generator on its own and through a
+// send that asks for one.
+func TestDecoyGeneration() {
+	fmt.Println("\n[Category 4] Decoy Generation Tests (In-Process)")
+
+	hidden := "Meet at the old pier\n- bring the documents\n- come alone\n- 23:00 sharp"
+	first := decoy.NewSeeded(nil, 7).For(hidden)
+	again := decoy.NewSeeded(nil, 7).For(hidden)
+	style := decoy.StyleOf(first)
+	if first == again && style.Kind == decoy.List && style.Bullet == "- " && len([]rune(first)) <= len([]rune(hidden)) {
+		fmt.Println("  ✅ Seeded decoys are reproducible and keep the hidden note's shape")
+	} else {
+		fmt.Printf("  ❌ Decoy mismatch. Got: %q\n", first)
+	}
+
+	corpus := &decoy.Corpus{Items: []string{"{fruit}"}, Sentences: []string{"Buy {fruit}."}, Words: map[string][]string{"fruit": {"figs"}}}
+	custom := decoy.NewSeeded(corpus, 1).Generate(30, decoy.Style{Kind: decoy.Prose})
+	broken := &decoy.Corpus{Items: []string{"{missing}"}, Sentences: []string{"x"}}
+	if custom == "Buy figs. Buy figs. Buy figs." && broken.Validate() != nil {
+		fmt.Println("  ✅ Operator corpora drive the output and are validated")
+	} else {
+		fmt.Printf("  ❌ Custom corpus mismatch. Got: %q\n", custom)
+	}
+
+	ts, stop := startServer(api.Config{Decoys: decoy.NewSeeded(nil, 3)})
+	defer stop()
+	postJSON(ts.URL, "/api/send", api.SendRequest{TxToken: "TX-decoy", RxToken: "RX-decoy", RealityB: hidden, Decoy: true})
+	surface, _, _ := readNoteFrom(ts.URL, "RX-decoy-A")
+	truth, _, _ := readNoteFrom(ts.URL, "RX-decoy-B")
+	if surface != "" && surface != "No note available" && decoy.StyleOf(surface).Kind == decoy.List && truth == hidden {
+		fmt.Println("  ✅ A send asking for a decoy gets a generated Reality A")
+	} else {
+		fmt.Printf("  ❌ Generated surface mismatch. Got: %q / %q\n", surface, truth)
+	}
+}
+
+// TestAccessPolicies sends entries guarded by compiled access policies to an
+// in-process server and reads them with and without meeting them.
+func TestAccessPolicies() {
+	fmt.Println("\n[Category 9] Access Policy Tests (In-Process)")
+
+	ts, stop := startServer(api.Config{SendLimiter: ratelimit.NewLimiter(100, 100, nil)})
+	defer stop()
+
+	send := func(rx, source string) {
+		postJSON(ts.URL, "/api/send", api.SendRequest{TxToken: "TX-policy", RxToken: rx, Realities: []string{"A", "B", "C"}, Policy: source})
+	}
+
+	send("RX-views", `cidr("127.0.0.0/8") && views(2)`)
+	send("RX-network", `cidr("10.0.0.0/8")`)
+	send("RX-work", `pow(10)`)
+	postJSON(ts.URL, "/api/send", api.SendRequest{
+		TxToken: "TX-policy", RxToken: "RX-geo", RealityA: "Here", RealityB: "B",
+		GeoActive: true, Lat: 52.52, Long: 13.40, RadiusKm: 5,
+	})
+	badSource := postJSON(ts.URL, "/api/send", api.SendRequest{TxToken: "TX-policy", RxToken: "RX-bad", RealityA: "A", RealityB: "B", Policy: "views("})
+
+	views := []string{
+		postJSON(ts.URL, "/api/read", api.ReadRequest{RxToken: "RX-views-A"}).Content,
+		postJSON(ts.URL, "/api/read", api.ReadRequest{RxToken: "RX-views-B"}).Content,
+		postJSON(ts.URL, "/api/read", api.ReadRequest{RxToken: "RX-views-C"}).Content,
+	}
+	if strings.Join(views, ",") == "A,B,No note available" {
+		fmt.Println("  ✅ views() and cidr() admit exactly two local reads")
+	} else {
+		fmt.Printf("  ❌ views() mismatch. Got: %v\n", views)
+	}
+
+	// cidr() judges the peer; a forwarded header only counts from a trusted proxy
+	spoofed := func(url string) string {
+		body, _ := json.Marshal(api.ReadRequest{RxToken: "RX-network"})
+		req, _ := http.NewRequest("POST", url+"/api/read", bytes.NewBuffer(body))
+		req.Header.Set("X-Forwarded-For", "10.1.2.3")
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			return ""
+		}
+		defer resp.Body.Close()
+		var out api.ReadResponse
+		json.NewDecoder(resp.Body).Decode(&out)
+		return out.Content
+	}
+	if spoofed(ts.URL) == "No note available" {
+		fmt.Println("  ✅ cidr() ignores X-Forwarded-For from an untrusted peer")
+	} else {
+		fmt.Println("  ❌ cidr() believed a forwarded address from an untrusted peer")
+	}
+	tsProxied, stopProxied := startServer(api.Config{TrustedProxies: []netip.Prefix{netip.MustParsePrefix("127.0.0.0/8")}})
+	defer stopProxied()
+	postJSON(tsProxied.URL, "/api/send", api.SendRequest{TxToken: "TX-policy", RxToken: "RX-network", RealityA: "A", RealityB: "B", Policy: `cidr("10.0.0.0/8")`})
+	if spoofed(tsProxied.URL) == "A" {
+		fmt.Println("  ✅ cidr() judges the client a trusted proxy forwards")
+	} else {
+		fmt.Println("  ❌ cidr() ignored the client a trusted proxy forwarded")
+	}
+
+	network := postJSON(ts.URL, "/api/read", api.ReadRequest{RxToken: "RX-network"}).Content
+	lazy := postJSON(ts.URL, "/api/read", api.ReadRequest{RxToken: "RX-work"}).Content
+	c := postJSON(ts.URL, "/api/challenge", api.ChallengeRequest{Bits: 10}).Challenge
+	worked := postJSON(ts.URL, "/api/read", api.ReadRequest{RxToken: "RX-work", Challenge: c, Solution: pow.Solve(c)}).Content
+	if network == "No note available" && lazy == "No note available" && worked == "A" {
+		fmt.Println("  ✅ cidr() and pow() refuse reads that do not meet them")
+	} else {
+		fmt.Printf("  ❌ cidr()/pow() mismatch. Got: '%s' / '%s' / '%s'\n", network, lazy, worked)
+	}
+
+	away := postJSON(ts.URL, "/api/read", api.ReadRequest{RxToken: "RX-geo", Lat: 48.85, Long: 2.35}).Content
+	here := postJSON(ts.URL, "/api/read", api.ReadRequest{RxToken: "RX-geo", Lat: 52.53, Long: 13.41}).Content
+	if away == "No note available" && here == "Here" && badSource.Content == "Note saved" {
+		fmt.Println("  ✅ Geofence enforced through the policy engine")
+	} else {
+		fmt.Printf("  ❌ Geofence mismatch. Got: '%s' / '%s'\n", away, here)
+	}
+}
+
 func main() {
+	TestClockDrivenTiming()
+	TestPagedRead()
+	TestBruteForceEscalation()
+	TestProofOfWorkAdmission()
+	TestBlindCredentials()
+	TestRecall()
+	TestReceipts()
+	TestReplyChain()
+	TestThresholdRelease()
+	TestTwoPersonRule()
+	TestReadWindows()
+	TestAccessPolicies()
+	TestViewPolicies()
+	TestFanOut()
+	TestGroupChannels()
+	TestSenderSignatures()
+	TestDeniableContainers()
+	TestDecoyGeneration()
 	TestDualRealityAndLifecycle()
 	TestFailureNormalization()
 	TestAuthAndAbuse()
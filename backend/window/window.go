@@ -0,0 +1,63 @@
+package window
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Time windows shared by read schedules and access policies, so that a
+// daily("08:00", "08:15") predicate and a schedule's daily window open
+// and close at exactly the same instants.
+
+var ErrClock = errors.New("window: malformed time of day")
+
+// ParseClock reads "HH:MM" as time since midnight. "24:00" ends a day.
+func ParseClock(s string) (time.Duration, error) {
+	hh, mm, found := strings.Cut(s, ":")
+	h, errH := strconv.Atoi(hh)
+	m, errM := strconv.Atoi(mm)
+	if !found || errH != nil || errM != nil || h < 0 || m < 0 || m > 59 || h*60+m > 24*60 {
+		return 0, ErrClock
+	}
+	return time.Duration(h)*time.Hour + time.Duration(m)*time.Minute, nil
+}
+
+// Daily opens every day from Start to End, both measured from local
+// midnight. End before Start wraps past midnight.
+type Daily struct {
+	Start time.Duration
+	End   time.Duration
+}
+
+// Contains reports whether t falls inside the window in loc (UTC if nil).
+// Both bounds are always compared, whichever way the window lies.
+func (w Daily) Contains(t time.Time, loc *time.Location) bool {
+	if loc == nil {
+		loc = time.UTC
+	}
+	// Read the wall clock rather than elapsed time since midnight, which
+	// is an hour off on days when daylight saving time starts or ends.
+	local := t.In(loc)
+	sinceMidnight := time.Duration(local.Hour())*time.Hour +
+		time.Duration(local.Minute())*time.Minute +
+		time.Duration(local.Second())*time.Second +
+		time.Duration(local.Nanosecond())
+	afterStart, beforeEnd := sinceMidnight >= w.Start, sinceMidnight < w.End
+	if w.Start <= w.End {
+		return afterStart && beforeEnd
+	}
+	return afterStart || beforeEnd
+}
+
+// Interval opens once, from From up to To.
+type Interval struct {
+	From time.Time
+	To   time.Time
+}
+
+// Contains reports whether t falls inside the interval.
+func (iv Interval) Contains(t time.Time) bool {
+	return !t.Before(iv.From) && t.Before(iv.To)
+}